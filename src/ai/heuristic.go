@@ -0,0 +1,118 @@
+package ai
+
+import (
+	"superfrink.net/tetris/engine"
+)
+
+// DOC: Weights of the board heuristic.  Each measure of a field, once the
+// complete rows are cleared, is multiplied by its weight and the sum is the
+// score of the field.  Higher scores are better so weights of bad measures
+// are negative.
+type Weights struct {
+	// AggregateHeight is the sum of the heights of the columns.
+	AggregateHeight float64
+	// Holes is the number of empty cells with a block above them.
+	Holes float64
+	// Bumpiness is the sum of the differences in height of neighbouring columns.
+	Bumpiness float64
+	// Wells is the sum of the depths of the columns lower than both of
+	// their neighbours, with the walls as tall as the field.
+	Wells float64
+	// Lines is the number of complete rows.
+	Lines float64
+}
+
+// DOC: Weights that play well on the standard field.
+var DefaultWeights = Weights{
+	AggregateHeight: -0.510066,
+	Holes:           -0.35663,
+	Bumpiness:       -0.184483,
+	Wells:           -0.05,
+	Lines:           0.760666,
+}
+
+// Evaluate scores the field of the game with the piece placed on it.
+// Returns:
+// - the score of the field, higher is better
+func (w Weights) Evaluate(g *engine.Game, p Placement) float64 {
+
+	field := make([][]int, len(g.Field))
+	for i := range g.Field {
+		field[i] = append([]int{}, g.Field[i]...)
+	}
+
+	blocks := g.PieceMap[p.Piece][p.Rotation]
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if 0 != blocks[i][j] {
+				field[p.Row+i][p.Col+j] = 1
+			}
+		}
+	}
+
+	return w.EvaluateField(field, g.GameRows, g.GameColumns)
+}
+
+// EvaluateField scores a field, with walls, of the number of rows and columns.
+// Returns:
+// - the score of the field, higher is better
+func (w Weights) EvaluateField(field [][]int, rows int, cols int) float64 {
+
+	// GOAL: keep the rows inside the walls that are not complete
+	var remaining [][]int
+	lines := 0
+	for i := 1; i < rows+1; i++ {
+		complete := true
+		for j := 1; j < cols+1; j++ {
+			if 0 == field[i][j] {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			lines++
+		} else {
+			remaining = append(remaining, field[i])
+		}
+	}
+
+	// GOAL: measure each column, with a wall as tall as the field each side
+	heights := make([]int, cols+2)
+	heights[0] = rows
+	heights[cols+1] = rows
+	holes := 0
+	for j := 1; j < cols+1; j++ {
+		top := -1
+		for i, row := range remaining {
+			if 0 != row[j] {
+				if -1 == top {
+					top = i
+				}
+			} else if -1 != top {
+				holes++
+			}
+		}
+		if -1 != top {
+			heights[j] = len(remaining) - top
+		}
+	}
+
+	height := 0
+	bumpiness := 0
+	wells := 0
+	for j := 1; j < cols+1; j++ {
+		height += heights[j]
+		if 1 < j {
+			bumpiness += max(heights[j]-heights[j-1], heights[j-1]-heights[j])
+		}
+		if depth := min(heights[j-1], heights[j+1]) - heights[j]; 0 < depth {
+			wells += depth
+		}
+	}
+
+	return w.AggregateHeight*float64(height) +
+		w.Holes*float64(holes) +
+		w.Bumpiness*float64(bumpiness) +
+		w.Wells*float64(wells) +
+		w.Lines*float64(lines)
+}
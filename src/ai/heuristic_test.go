@@ -0,0 +1,62 @@
+package ai
+
+import (
+	"testing"
+)
+
+// testField creates a field with walls from rows of cells inside the walls,
+// written top to bottom with 'X' for a block.
+func testField(rows ...string) [][]int {
+
+	cols := len(rows[0])
+	field := make([][]int, len(rows)+2)
+	for i := range field {
+		field[i] = make([]int, cols+2)
+		field[i][0] = 1
+		field[i][cols+1] = 1
+	}
+	for j := range field[0] {
+		field[0][j] = 1
+		field[len(rows)+1][j] = 1
+	}
+	for i, row := range rows {
+		for j, c := range row {
+			if 'X' == c {
+				field[i+1][j+1] = 1
+			}
+		}
+	}
+
+	return field
+}
+
+func TestEvaluateField(t *testing.T) {
+
+	field := testField(
+		"....",
+		".X..",
+		".X.X",
+		"XXXX",
+		"X.XX",
+	)
+
+	tests := []struct {
+		name    string
+		weights Weights
+		want    float64
+	}{
+		// CLAIM: after the full row is cleared the heights are 1 3 1 2, between
+		// walls 5 high
+		{"height", Weights{AggregateHeight: 1}, 7},
+		{"holes", Weights{Holes: 1}, 1},
+		{"bumpiness", Weights{Bumpiness: 1}, 5},
+		{"wells", Weights{Wells: 1}, 3},
+		{"lines", Weights{Lines: 1}, 1},
+	}
+
+	for _, test := range tests {
+		if got := test.weights.EvaluateField(field, 5, 4); got != test.want {
+			t.Errorf("Field measure not expected.  %s  got: %v  want: %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package ai
+
+import (
+	"superfrink.net/tetris/engine"
+)
+
+// DOC: A Player chooses the inputs that take each piece to the best placement.
+type Player struct {
+	Weights Weights
+}
+
+// NewPlayer creates a player with the heuristic weights.
+func NewPlayer(weights Weights) *Player {
+	return &Player{Weights: weights}
+}
+
+// Best finds the placement that leaves the best field.  Ties go to the
+// placement with the shorter path so the choice does not depend on the order
+// of the search.
+// Returns:
+// - the best placement
+// - false if the piece cannot be placed anywhere
+func (p *Player) Best(g *engine.Game) (Placement, bool) {
+
+	var best Placement
+	best_score := 0.0
+	found := false
+
+	for _, placement := range Placements(g) {
+		score := p.Weights.Evaluate(g, placement)
+		if !found || score > best_score || (score == best_score && len(placement.Path) < len(best.Path)) {
+			best, best_score, found = placement, score, true
+		}
+	}
+
+	return best, found
+}
+
+// Inputs chooses the next player input for the game as it is now.  The search
+// starts from where the piece is, so the player keeps up with a piece that
+// gravity moved since the last input.  The game is only read, so a copy from
+// CopyOfState will do.
+// Returns:
+// - the inputs for the next step, nil when the game is not running
+func (p *Player) Inputs(g *engine.Game) []byte {
+
+	if engine.StateRunning != g.State {
+		return nil
+	}
+
+	best, ok := p.Best(g)
+	if !ok {
+		// CLAIM: the piece cannot move so lock it where it is
+		return []byte{engine.PlayInputHardDrop}
+	}
+
+	return best.Path[:1]
+}
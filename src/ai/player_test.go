@@ -0,0 +1,54 @@
+package ai
+
+import (
+	"testing"
+	"time"
+
+	"superfrink.net/tetris/engine"
+)
+
+func TestBestClearsLine(t *testing.T) {
+
+	g := newTestGame(t, 1)
+	g.HoldUsed = true
+
+	// GOAL: a bottom row with a gap only an upright I piece fills
+	for j := 1; j < g.GameColumns+1; j++ {
+		for i := g.GameRows - 3; i < g.GameRows+1; i++ {
+			if 5 != j {
+				g.Field[i][j] = 1
+			}
+		}
+	}
+	g.Piece = 0
+
+	best, ok := NewPlayer(DefaultWeights).Best(g)
+	if !ok {
+		t.Fatalf("No placement found.")
+	}
+
+	g.Step(best.Path, 0)
+	if g.ScoreLineCount != 4 {
+		t.Errorf("Lines cleared not expected.  got: %d  want: %d\n%s", g.ScoreLineCount, 4, g.GetDebugState())
+	}
+}
+
+func TestPlayerPlays(t *testing.T) {
+
+	g := newTestGame(t, 2)
+	player := NewPlayer(DefaultWeights)
+
+	for tick := 0; tick < 20000 && g.ScorePieceCount < 100; tick++ {
+		g.Step(player.Inputs(g), time.Second/60)
+		if engine.StateGameOver == g.State {
+			t.Fatalf("Game over after %d pieces.\n%s", g.ScorePieceCount, g.GetDebugState())
+		}
+	}
+
+	if g.ScorePieceCount < 100 {
+		t.Errorf("Player too slow.  pieces: %d", g.ScorePieceCount)
+	}
+	if g.ScoreLineCount < 30 {
+		t.Errorf("Player cleared too few lines.  got: %d", g.ScoreLineCount)
+	}
+}
@@ -0,0 +1,41 @@
+// Package ai plays tetris by searching every place the piece in play, or the
+// held piece, can reach and choosing the place that leaves the best field.
+package ai
+
+import (
+	"superfrink.net/tetris/engine"
+)
+
+// DOC: A Placement is a place a piece can lock in, found by the engine, and
+// whether the piece must be held first to play it.  The path of a held
+// placement starts with the hold input.
+type Placement struct {
+	engine.Placement
+	Hold bool
+}
+
+// Placements finds every distinct place the piece in play can lock in, and
+// when holding is allowed and the piece it would bring into play is known,
+// every place that piece can lock in after holding.
+// Returns:
+// - the placements, each with a shortest path that ends in a hard drop
+func Placements(g *engine.Game) []Placement {
+
+	var placements []Placement
+	for _, p := range g.Placements() {
+		placements = append(placements, Placement{Placement: p})
+	}
+
+	if !g.HoldUsed {
+		held := g.HoldPiece
+		if held < 0 && 0 < len(g.NextPieces) {
+			held = g.NextPieces[0]
+		}
+		for _, p := range g.SpawnPlacements(held) {
+			p.Path = append([]byte{engine.PlayInputHold}, p.Path...)
+			placements = append(placements, Placement{Placement: p, Hold: true})
+		}
+	}
+
+	return placements
+}
@@ -0,0 +1,68 @@
+package ai
+
+import (
+	"testing"
+
+	"superfrink.net/tetris/engine"
+)
+
+// newTestGame creates a stepped standard game with an empty hold.
+func newTestGame(t *testing.T, seed int64) *engine.Game {
+	config := engine.DefaultConfig()
+	config.Seed = seed
+	config.Randomizer = engine.NewBagRandomizer(1)
+	g, err := engine.NewSteppedGame(config)
+	if nil != err {
+		t.Fatalf("Game not created.  %v", err)
+	}
+	return g
+}
+
+func TestPlacementsHold(t *testing.T) {
+
+	g := newTestGame(t, 2)
+	next := g.NextPieces[0]
+
+	held := 0
+	for _, p := range Placements(g) {
+		if p.Hold {
+			held++
+			if p.Piece != next || engine.PlayInputHold != p.Path[0] {
+				t.Errorf("Hold placement not expected.  %+v", p)
+			}
+		}
+	}
+	if 0 == held {
+		t.Errorf("No placements after holding.")
+	}
+
+	g.HoldUsed = true
+	for _, p := range Placements(g) {
+		if p.Hold {
+			t.Errorf("Hold placement after holding.  %+v", p)
+		}
+	}
+}
+
+func TestPlacementsPathsLock(t *testing.T) {
+
+	g := newTestGame(t, 3)
+
+	for _, p := range Placements(g) {
+		played := newTestGame(t, 3)
+		played.Step(p.Path, 0)
+
+		if played.ScorePieceCount == g.ScorePieceCount {
+			t.Fatalf("Path did not lock the piece.  %+v", p)
+		}
+
+		blocks := g.PieceMap[p.Piece][p.Rotation]
+		for i := 0; i < 4; i++ {
+			for j := 0; j < 4; j++ {
+				if 0 != blocks[i][j] && 0 == played.Field[p.Row+i][p.Col+j] {
+					t.Fatalf("Piece not locked in its placement.  %+v\n%s", p, played.GetDebugState())
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Command tetris-server hosts tetris games over TCP for remote players and
+// spectators.  See package server for the protocol.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+
+	"superfrink.net/tetris/engine"
+	"superfrink.net/tetris/server"
+)
+
+func main() {
+
+	var flag_addr = flag.String("addr", ":7070", "Address to listen on.")
+	var flag_randomizer = flag.String("randomizer", "random", "Piece randomizer: random, bag7, bag14 or tgm.")
+	var flag_bucketgame = flag.Bool("b", false, "Host bucket games instead.")
+	flag.Parse()
+
+	randomizer, err := engine.NewRandomizer(*flag_randomizer)
+	if nil != err {
+		log.Fatal("randomizer: ", err)
+	}
+
+	config := engine.DefaultConfig()
+	if *flag_bucketgame {
+		config = engine.DefaultBucketConfig()
+	}
+	config.Randomizer = randomizer
+
+	s, err := server.NewServer(config)
+	if nil != err {
+		log.Fatal("server: ", err)
+	}
+
+	listener, err := net.Listen("tcp", *flag_addr)
+	if nil != err {
+		log.Fatal("listen: ", err)
+	}
+	log.Printf("listening on %s", listener.Addr())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := s.Serve(ctx, listener); nil != err {
+		log.Fatal("serve: ", err)
+	}
+}
@@ -0,0 +1,155 @@
+// Command tetris-sim plays batches of seeded tetris games without a screen,
+// with a policy choosing the player inputs, and writes the result of each game.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+
+	"superfrink.net/tetris/engine"
+)
+
+// writeCSV writes the results as CSV with a header row.
+func writeCSV(w io.Writer, results []Result) error {
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"seed", "pieces", "lines", "level", "score", "ticks", "game_over"})
+
+	for _, r := range results {
+		writer.Write([]string{
+			strconv.FormatInt(r.Seed, 10),
+			strconv.Itoa(r.Pieces),
+			strconv.Itoa(r.Lines),
+			strconv.Itoa(r.Level),
+			strconv.Itoa(r.Score),
+			strconv.Itoa(r.Ticks),
+			strconv.FormatBool(r.GameOver),
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeJSONLines writes the results as one JSON object per line.
+func writeJSONLines(w io.Writer, results []Result) error {
+
+	encoder := json.NewEncoder(w)
+	for _, r := range results {
+		if err := encoder.Encode(r); nil != err {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// policyFactory creates the factory for the named policy.
+func policyFactory(name string, script_path string) (PolicyFactory, error) {
+
+	switch name {
+	case "random":
+		return NewRandomPolicy, nil
+	case "greedy":
+		return NewGreedyPolicy, nil
+	case "ai":
+		return NewAIPolicy, nil
+	case "script":
+		if "" == script_path {
+			return nil, fmt.Errorf("the script policy needs a -script file")
+		}
+		file, err := os.Open(script_path)
+		if nil != err {
+			return nil, err
+		}
+		defer file.Close()
+		return ReadScript(file)
+	}
+
+	return nil, fmt.Errorf("unknown policy %q", name)
+}
+
+func main() {
+
+	var flag_games = flag.Int("n", 100, "Number of games to play.")
+	var flag_seed = flag.Int64("seed", 1, "Seed of the first game.  Each game uses the next seed.")
+	var flag_policy = flag.String("policy", "random", "Policy choosing the inputs: random, script, greedy or ai.")
+	var flag_script = flag.String("script", "", "Input script file for the script policy.")
+	var flag_randomizer = flag.String("randomizer", "random", "Piece randomizer: random, bag7, bag14 or tgm.")
+	var flag_bucketgame = flag.Bool("b", false, "Play bucket games instead.")
+	var flag_tick = flag.Duration("tick", time.Second/60, "Game time between policy inputs.")
+	var flag_max_ticks = flag.Int("max-ticks", 100000, "End a game after this many ticks.")
+	var flag_workers = flag.Int("workers", runtime.NumCPU(), "Number of games to play at once.")
+	var flag_format = flag.String("format", "csv", "Output format: csv or jsonl.")
+	var flag_output = flag.String("o", "", "Output file instead of standard output.")
+	flag.Parse()
+
+	new_policy, err := policyFactory(*flag_policy, *flag_script)
+	if nil != err {
+		log.Fatal("policy: ", err)
+	}
+
+	randomizer, err := engine.NewRandomizer(*flag_randomizer)
+	if nil != err {
+		log.Fatal("randomizer: ", err)
+	}
+
+	var write func(io.Writer, []Result) error
+	switch *flag_format {
+	case "csv":
+		write = writeCSV
+	case "jsonl":
+		write = writeJSONLines
+	default:
+		log.Fatalf("format: unknown format %q", *flag_format)
+	}
+
+	if *flag_tick <= 0 {
+		log.Fatal("tick: must be more than zero")
+	}
+
+	config := engine.DefaultConfig()
+	if *flag_bucketgame {
+		config = engine.DefaultBucketConfig()
+	}
+	config.Randomizer = randomizer
+
+	simulation := Simulation{
+		Config:    config,
+		NewPolicy: new_policy,
+		Tick:      *flag_tick,
+		MaxTicks:  *flag_max_ticks,
+	}
+
+	seeds := make([]int64, max(*flag_games, 0))
+	for i := range seeds {
+		seeds[i] = *flag_seed + int64(i)
+	}
+
+	results, err := simulation.Run(seeds, *flag_workers)
+	if nil != err {
+		log.Fatal("simulate: ", err)
+	}
+
+	output := io.Writer(os.Stdout)
+	if "" != *flag_output {
+		file, err := os.Create(*flag_output)
+		if nil != err {
+			log.Fatal("output: ", err)
+		}
+		defer file.Close()
+		output = file
+	}
+
+	if err := write(output, results); nil != err {
+		log.Fatal("output: ", err)
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+
+	"superfrink.net/tetris/ai"
+	"superfrink.net/tetris/engine"
+)
+
+// DOC: A Policy plays a game by choosing the player inputs for each tick.
+type Policy interface {
+	// Inputs returns the player inputs for the next tick of the game.
+	Inputs(g *engine.Game) []byte
+}
+
+// DOC: A PolicyFactory creates the policy for one game.  Policies can keep
+// state so every game has its own.
+type PolicyFactory func(seed int64) Policy
+
+// DOC: Player inputs a policy may choose, by the console game key for each.
+var keyInputs = map[rune]byte{
+	'h': engine.PlayInputMoveLeft,
+	'l': engine.PlayInputMoveRight,
+	'r': engine.PlayInputRotate,
+	'e': engine.PlayInputRotateCCW,
+	'w': engine.PlayInputRotate180,
+	'd': engine.PlayInputDrop,
+	's': engine.PlayInputHardDrop,
+	'c': engine.PlayInputHold,
+}
+
+// DOC: Inputs the random policy chooses from, including doing nothing.
+var randomInputs = []byte{
+	engine.PlayInputMoveLeft,
+	engine.PlayInputMoveRight,
+	engine.PlayInputRotate,
+	engine.PlayInputRotateCCW,
+	engine.PlayInputRotate180,
+	engine.PlayInputDrop,
+	engine.PlayInputHardDrop,
+	engine.PlayInputHold,
+}
+
+// RandomPolicy presses a uniformly random key, or no key, every tick.
+type RandomPolicy struct {
+	prng *rand.Rand
+}
+
+// NewRandomPolicy creates a random policy with its own PRNG seed.
+func NewRandomPolicy(seed int64) Policy {
+	return &RandomPolicy{prng: rand.New(rand.NewSource(seed))}
+}
+
+// Inputs returns a random input or nothing.
+func (p *RandomPolicy) Inputs(g *engine.Game) []byte {
+
+	n := p.prng.Intn(len(randomInputs) + 1)
+	if n == len(randomInputs) {
+		return nil
+	}
+
+	return []byte{randomInputs[n]}
+}
+
+// ScriptPolicy plays a script of inputs, one line per tick, over and over.
+type ScriptPolicy struct {
+	script [][]byte
+	tick   int
+}
+
+// Inputs returns the inputs for the script line of the tick.
+func (p *ScriptPolicy) Inputs(g *engine.Game) []byte {
+
+	inputs := p.script[p.tick%len(p.script)]
+	p.tick++
+
+	return inputs
+}
+
+// ReadScript reads an input script.  Each line is one tick and holds the
+// console game keys pressed in that tick.  A line with only "." presses
+// nothing and everything after a "#" is a comment.  Blank lines are ignored.
+// Returns:
+// - A factory for policies that play the script
+// - An error if the script cannot be read or has no ticks
+func ReadScript(r io.Reader) (PolicyFactory, error) {
+
+	var script [][]byte
+
+	scanner := bufio.NewScanner(r)
+	for line_number := 1; scanner.Scan(); line_number++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if "" == line {
+			continue
+		}
+
+		var inputs []byte
+		if "." != line {
+			for _, key := range line {
+				input, ok := keyInputs[key]
+				if !ok {
+					return nil, fmt.Errorf("script line %d: unknown key %q", line_number, key)
+				}
+				inputs = append(inputs, input)
+			}
+		}
+		script = append(script, inputs)
+	}
+	if err := scanner.Err(); nil != err {
+		return nil, err
+	}
+	if 0 == len(script) {
+		return nil, fmt.Errorf("script has no ticks")
+	}
+
+	return func(seed int64) Policy {
+		return &ScriptPolicy{script: script}
+	}, nil
+}
+
+// GreedyPolicy picks the best place for each piece, by dropping it straight
+// down in every rotation and column, and then steers the piece there and hard
+// drops it.
+type GreedyPolicy struct {
+	piece_count int
+	rotation    int
+	column      int
+	ticks       int
+}
+
+// DOC: The number of ticks the greedy policy spends steering one piece
+// before it gives up and hard drops it where it is.
+const greedySteerTicks = 20
+
+// NewGreedyPolicy creates a greedy policy.  It has no randomness.
+func NewGreedyPolicy(seed int64) Policy {
+	return &GreedyPolicy{}
+}
+
+// Inputs returns the next input to steer the piece to its place.
+func (p *GreedyPolicy) Inputs(g *engine.Game) []byte {
+
+	if p.piece_count != g.ScorePieceCount {
+		// GOAL: plan the place for a new piece
+		p.piece_count = g.ScorePieceCount
+		p.rotation, p.column = greedyPlace(g)
+		p.ticks = 0
+	}
+	p.ticks++
+
+	switch {
+	case p.ticks > greedySteerTicks:
+		return []byte{engine.PlayInputHardDrop}
+	case g.PieceRotation != p.rotation:
+		return []byte{engine.PlayInputRotate}
+	case g.PiecePosCol < p.column:
+		return []byte{engine.PlayInputMoveRight}
+	case g.PiecePosCol > p.column:
+		return []byte{engine.PlayInputMoveLeft}
+	}
+
+	return []byte{engine.PlayInputHardDrop}
+}
+
+// greedyPlace finds the rotation and column where dropping the piece in play
+// leaves the best field by the board heuristic of the ai package.
+func greedyPlace(g *engine.Game) (int, int) {
+
+	best_rotation, best_column := g.PieceRotation, g.PiecePosCol
+	best_score := 0.0
+	found := false
+
+	for rotation := 0; rotation < 4; rotation++ {
+		for column := -3; column < g.GameColumns+1; column++ {
+			if !fits(g, rotation, g.PiecePosRow, column) {
+				continue
+			}
+
+			row := g.PiecePosRow
+			for fits(g, rotation, row+1, column) {
+				row++
+			}
+
+			score := ai.DefaultWeights.EvaluateField(placed(g, rotation, row, column), g.GameRows, g.GameColumns)
+			if !found || score > best_score {
+				best_rotation, best_column, best_score, found = rotation, column, score, true
+			}
+		}
+	}
+
+	return best_rotation, best_column
+}
+
+// fits determines whether the piece in play fits in the field in the rotation
+// at the row and column.
+func fits(g *engine.Game, rotation int, row int, col int) bool {
+
+	blocks := g.PieceMap[g.Piece][rotation]
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if 0 == blocks[i][j] {
+				continue
+			}
+			if row+i < 0 || row+i >= len(g.Field) || col+j < 0 || col+j >= len(g.Field[row+i]) {
+				return false
+			}
+			if 0 != g.Field[row+i][col+j] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// placed returns a copy of the field with the piece in play placed in the
+// rotation at the row and column.
+func placed(g *engine.Game, rotation int, row int, col int) [][]int {
+
+	field := make([][]int, len(g.Field))
+	for i := range g.Field {
+		field[i] = append([]int{}, g.Field[i]...)
+	}
+
+	blocks := g.PieceMap[g.Piece][rotation]
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if 0 != blocks[i][j] {
+				field[row+i][col+j] = 1
+			}
+		}
+	}
+
+	return field
+}
+
+// NewAIPolicy creates a policy played by the placement search of the ai
+// package with its default weights.
+func NewAIPolicy(seed int64) Policy {
+	return ai.NewPlayer(ai.DefaultWeights)
+}
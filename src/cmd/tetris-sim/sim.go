@@ -0,0 +1,102 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"superfrink.net/tetris/engine"
+)
+
+// DOC: Result describes how one simulated game went.
+type Result struct {
+	Seed     int64 `json:"seed"`
+	Pieces   int   `json:"pieces"`
+	Lines    int   `json:"lines"`
+	Level    int   `json:"level"`
+	Score    int   `json:"score"`
+	Ticks    int   `json:"ticks"`
+	GameOver bool  `json:"game_over"`
+}
+
+// DOC: Simulation describes a batch of games to play.
+type Simulation struct {
+	// Config is used for every game, with the seed of each game.
+	Config engine.Config
+
+	// NewPolicy creates the policy that plays each game.
+	NewPolicy PolicyFactory
+
+	// Tick is the game time between policy inputs.
+	Tick time.Duration
+
+	// MaxTicks ends a game that is not over after this many ticks.
+	MaxTicks int
+}
+
+// RunGame plays one game with the seed until it is over or runs out of ticks.
+// Returns:
+// - The result of the game
+// - An error if the game cannot be created
+func (s Simulation) RunGame(seed int64) (Result, error) {
+
+	config := s.Config
+	config.Seed = seed
+
+	g, err := engine.NewSteppedGame(config)
+	if nil != err {
+		return Result{}, err
+	}
+	policy := s.NewPolicy(seed)
+
+	ticks := 0
+	for ; ticks < s.MaxTicks && engine.StateGameOver != g.State; ticks++ {
+		g.Step(policy.Inputs(g), s.Tick)
+	}
+
+	return Result{
+		Seed:     seed,
+		Pieces:   g.ScorePieceCount,
+		Lines:    g.ScoreLineCount,
+		Level:    g.Level,
+		Score:    g.Score,
+		Ticks:    ticks,
+		GameOver: engine.StateGameOver == g.State,
+	}, nil
+}
+
+// Run plays a game for each seed on the number of workers at once.
+// Returns:
+// - The result of each game in the order of the seeds
+// - The first error from creating a game
+func (s Simulation) Run(seeds []int64, workers int) ([]Result, error) {
+
+	results := make([]Result, len(seeds))
+	errs := make([]error, len(seeds))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < max(workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = s.RunGame(seeds[i])
+			}
+		}()
+	}
+
+	for i := range seeds {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if nil != err {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"superfrink.net/tetris/engine"
+)
+
+// testSimulation returns a simulation of standard games with the policy.
+func testSimulation(new_policy PolicyFactory) Simulation {
+	config := engine.DefaultConfig()
+	config.Randomizer = engine.NewBagRandomizer(1)
+
+	return Simulation{
+		Config:    config,
+		NewPolicy: new_policy,
+		Tick:      time.Second / 60,
+		MaxTicks:  20000,
+	}
+}
+
+func TestRunDeterministic(t *testing.T) {
+
+	seeds := []int64{1, 2, 3, 4, 5, 6}
+	simulation := testSimulation(NewRandomPolicy)
+
+	serial, err := simulation.Run(seeds, 1)
+	if nil != err {
+		t.Fatalf("Simulation failed.  %v", err)
+	}
+	parallel, err := simulation.Run(seeds, 4)
+	if nil != err {
+		t.Fatalf("Simulation failed.  %v", err)
+	}
+
+	if !slices.Equal(serial, parallel) {
+		t.Errorf("Parallel results not expected.  got: %v  want: %v", parallel, serial)
+	}
+	for i, r := range serial {
+		if r.Seed != seeds[i] {
+			t.Errorf("Result out of order.  got: %d  want: %d", r.Seed, seeds[i])
+		}
+	}
+}
+
+func TestGreedyClearsLines(t *testing.T) {
+
+	results, err := testSimulation(NewGreedyPolicy).Run([]int64{1, 2, 3}, 3)
+	if nil != err {
+		t.Fatalf("Simulation failed.  %v", err)
+	}
+
+	for _, r := range results {
+		if r.Lines < 10 {
+			t.Errorf("Greedy policy cleared too few lines.  %+v", r)
+		}
+	}
+}
+
+func TestReadScript(t *testing.T) {
+
+	new_policy, err := ReadScript(strings.NewReader("# move then drop\nhl\n\n.\ns # hard drop\n"))
+	if nil != err {
+		t.Fatalf("Script not read.  %v", err)
+	}
+
+	policy := new_policy(1)
+	want := [][]byte{
+		{engine.PlayInputMoveLeft, engine.PlayInputMoveRight},
+		nil,
+		{engine.PlayInputHardDrop},
+		{engine.PlayInputMoveLeft, engine.PlayInputMoveRight},
+	}
+	for i, w := range want {
+		if got := policy.Inputs(nil); !bytes.Equal(got, w) {
+			t.Errorf("Script tick %d not expected.  got: %v  want: %v", i, got, w)
+		}
+	}
+
+	for _, script := range []string{"", "# nothing\n", "hx\n"} {
+		if _, err := ReadScript(strings.NewReader(script)); nil == err {
+			t.Errorf("Script not rejected.  %q", script)
+		}
+	}
+}
+
+func TestWriteResults(t *testing.T) {
+
+	results := []Result{{Seed: 7, Pieces: 10, Lines: 2, Level: 1, Score: 300, Ticks: 99, GameOver: true}}
+
+	var buffer bytes.Buffer
+	if err := writeCSV(&buffer, results); nil != err {
+		t.Fatalf("CSV not written.  %v", err)
+	}
+	want := "seed,pieces,lines,level,score,ticks,game_over\n7,10,2,1,300,99,true\n"
+	if buffer.String() != want {
+		t.Errorf("CSV not expected.  got: %q  want: %q", buffer.String(), want)
+	}
+
+	buffer.Reset()
+	if err := writeJSONLines(&buffer, results); nil != err {
+		t.Fatalf("JSON lines not written.  %v", err)
+	}
+	want = `{"seed":7,"pieces":10,"lines":2,"level":1,"score":300,"ticks":99,"game_over":true}` + "\n"
+	if buffer.String() != want {
+		t.Errorf("JSON lines not expected.  got: %q  want: %q", buffer.String(), want)
+	}
+}
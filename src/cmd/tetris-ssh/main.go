@@ -0,0 +1,60 @@
+// Command tetris-ssh hosts tetris games over SSH.  Connect with any SSH
+// client, such as ssh -p 2222 localhost, to play in the terminal.  See package
+// sshserver for the lobby.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+
+	"golang.org/x/crypto/ssh"
+	"superfrink.net/tetris/engine"
+	"superfrink.net/tetris/sshserver"
+)
+
+func main() {
+
+	var flag_addr = flag.String("addr", ":2222", "Address to listen on.")
+	var flag_host_key = flag.String("host-key", "tetris_host_key", "Host key file, created if it does not exist.")
+	var flag_randomizer = flag.String("randomizer", "random", "Piece randomizer: random, bag7, bag14 or tgm.")
+	var flag_bucketgame = flag.Bool("b", false, "Host bucket games instead.")
+	flag.Parse()
+
+	randomizer, err := engine.NewRandomizer(*flag_randomizer)
+	if nil != err {
+		log.Fatal("randomizer: ", err)
+	}
+
+	host_key, err := sshserver.LoadHostKey(*flag_host_key)
+	if nil != err {
+		log.Fatal("host key: ", err)
+	}
+
+	config := engine.DefaultConfig()
+	if *flag_bucketgame {
+		config = engine.DefaultBucketConfig()
+	}
+	config.Randomizer = randomizer
+
+	s, err := sshserver.NewServer(host_key, config)
+	if nil != err {
+		log.Fatal("server: ", err)
+	}
+
+	listener, err := net.Listen("tcp", *flag_addr)
+	if nil != err {
+		log.Fatal("listen: ", err)
+	}
+	log.Printf("listening on %s with host key %s", listener.Addr(), ssh.FingerprintSHA256(host_key.PublicKey()))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := s.Serve(ctx, listener); nil != err {
+		log.Fatal("serve: ", err)
+	}
+}
@@ -0,0 +1,41 @@
+// Command tetris-web hosts tetris games for browsers.  It serves a canvas
+// client at / and the API of package web under /api.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"superfrink.net/tetris/web"
+)
+
+func main() {
+
+	var flag_addr = flag.String("addr", ":8080", "Address to listen on.")
+	var flag_idle = flag.Duration("idle", 5*time.Minute, "Stop games without input for this long, 0 to never stop them.")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	http_server := &http.Server{Addr: *flag_addr, Handler: web.NewServer(ctx, *flag_idle)}
+
+	// GOAL: stop serving once interrupted
+	go func() {
+		<-ctx.Done()
+		shutdown_ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		http_server.Shutdown(shutdown_ctx)
+	}()
+
+	log.Printf("listening on %s", *flag_addr)
+	if err := http_server.ListenAndServe(); nil != err && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal("serve: ", err)
+	}
+}
@@ -0,0 +1,209 @@
+package engine
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// DOC: A Clock tells the main game loop the time and wakes it up when the
+// game is next due to change.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// DOC: A Timer sends the time on its channel once it is due, like a
+// time.Timer.  A loop that waits on and off keeps one timer and resets it
+// rather than starting a new one for every wait.
+type Timer interface {
+	C() <-chan time.Time
+	Reset(d time.Duration)
+	Stop()
+}
+
+// SystemClock is the wall clock.
+type SystemClock struct{}
+
+// Now returns the current time.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer starts a timer that sends the current time after the duration.
+func (SystemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+// systemTimer is a Timer of the wall clock.
+type systemTimer struct {
+	timer *time.Timer
+}
+
+// C returns the channel the time is sent on.
+func (t systemTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+// Reset stops the timer, drops a time that was sent and not received, and
+// starts it again for the duration.
+func (t systemTimer) Reset(d time.Duration) {
+	if !t.timer.Stop() {
+		select {
+		case <-t.timer.C:
+		default:
+		}
+	}
+	t.timer.Reset(d)
+}
+
+// Stop stops the timer.
+func (t systemTimer) Stop() {
+	t.timer.Stop()
+}
+
+// ManualClock is a clock that only moves when Advance is called, so a main
+// game loop can be driven without waiting on real time.
+type ManualClock struct {
+	mutex   sync.Mutex
+	waiting *sync.Cond
+	now     time.Time
+	waiters []*manualTimer
+}
+
+// manualTimer is a Timer of a ManualClock.  It is waiting while it is in the
+// waiters of its clock.
+type manualTimer struct {
+	clock *ManualClock
+	at    time.Time
+	ch    chan time.Time
+}
+
+// NewManualClock creates a manual clock showing the start time.
+func NewManualClock(start time.Time) *ManualClock {
+	c := &ManualClock{now: start}
+	c.waiting = sync.NewCond(&c.mutex)
+	return c
+}
+
+// Now returns the time the clock shows.
+func (c *ManualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+// NewTimer starts a timer that is sent the time once the clock has been
+// advanced by the duration.
+func (c *ManualClock) NewTimer(d time.Duration) Timer {
+	t := &manualTimer{clock: c, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// C returns the channel the time is sent on.
+func (t *manualTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+// Reset stops the timer, drops a time that was sent and not received, and
+// starts it again for the duration.
+func (t *manualTimer) Reset(d time.Duration) {
+	c := t.clock
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	t.stop()
+	select {
+	case <-t.ch:
+	default:
+	}
+
+	if d <= 0 {
+		t.ch <- c.now
+		return
+	}
+
+	t.at = c.now.Add(d)
+	c.waiters = append(c.waiters, t)
+	c.waiting.Broadcast()
+}
+
+// Stop stops the timer.
+func (t *manualTimer) Stop() {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	t.stop()
+}
+
+// stop removes the timer from the waiters of its clock.  The clock must be
+// locked.
+func (t *manualTimer) stop() {
+	c := t.clock
+	c.waiters = slices.DeleteFunc(c.waiters, func(w *manualTimer) bool { return w == t })
+}
+
+// WaitForWaiter blocks until something is waiting on the clock, so that a
+// following Advance is sure to wake it.
+func (c *ManualClock) WaitForWaiter() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for 0 == len(c.waiters) {
+		c.waiting.Wait()
+	}
+}
+
+// Advance moves the clock forward by the duration and wakes every waiter
+// that is then due.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+
+	waiting := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiting = append(waiting, w)
+		} else {
+			w.ch <- c.now
+		}
+	}
+	c.waiters = waiting
+}
+
+// wakeTimer wakes a loop when its game is next due, reusing one timer of the
+// clock for every wait.
+type wakeTimer struct {
+	clock Clock
+	timer Timer
+}
+
+// wake starts the timer for the duration when the game is due.
+// Returns:
+// - the channel the time is sent on once the game is due
+// - nil if the game is not due, so waiting on it never wakes the loop
+func (w *wakeTimer) wake(d time.Duration, due bool) <-chan time.Time {
+
+	switch {
+	case !due:
+		w.stop()
+		return nil
+	case nil == w.timer:
+		w.timer = w.clock.NewTimer(d)
+	default:
+		w.timer.Reset(d)
+	}
+
+	return w.timer.C()
+}
+
+// stop stops the timer if it was started.
+func (w *wakeTimer) stop() {
+	if nil != w.timer {
+		w.timer.Stop()
+	}
+}
@@ -0,0 +1,191 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+)
+
+// DOC: A Config describes how to set up a new game.  A zero RotationSystem,
+// Randomizer or Gravity uses the default and a zero NumberPossiblePieces
+// uses every piece in the PieceMap.  The default RotationSystem is
+// DefaultRotationSystem for the SRSPieceMap and ClassicRotation for any other
+// piece map, since the SRS kicks only fit the SRS orientations.  A fixed tick
+// rate, where pieces fall at the same speed on every level, is a Gravity of
+// TableGravity{interval}.
+type Config struct {
+	// Seed for the generation of pieces to be played.
+	Seed int64
+
+	// Rows and Columns are the size of the field inside the walls.
+	Rows    int
+	Columns int
+
+	// NumberPossiblePieces is how many of the pieces in PieceMap are played.
+	// Each piece has 4 rotations of at least 4 by 4 blocks.
+	NumberPossiblePieces int
+	PieceMap             [][][][]int
+	RotationSystem       RotationSystem
+
+	// QueueLength is the number of upcoming pieces shown in NextPieces.
+	QueueLength int
+
+	// Randomizer picks the sequence of pieces.  Each game is given its own
+	// copy, so one configuration can create many games.
+	Randomizer Randomizer
+
+	// Gravity gives the fall speed at each level.  LinesPerLevel is the
+	// number of lines cleared to go up a level, zero to stay on level 1.
+	Gravity       GravityCurve
+	LinesPerLevel int
+
+	// LockDelay is how long a landed piece waits before it locks, zero to
+	// lock at once.  LockResets is how many times moving or rotating a landed
+	// piece restarts the lock delay.
+	LockDelay  time.Duration
+	LockResets int
+
+	// SpawnColumn is the field column of the left side of the piece map when
+	// a piece comes into play, zero for the middle of the field.  Pieces
+	// always come into play on the top row.
+	SpawnColumn int
+}
+
+// DefaultConfig returns the configuration of a standard tetris game.
+func DefaultConfig() Config {
+	return Config{
+		Rows:                 DefaultGameRows,
+		Columns:              DefaultGameColumns,
+		NumberPossiblePieces: DefaultNumberPossiblePieces,
+		PieceMap:             SRSPieceMap,
+		RotationSystem:       DefaultRotationSystem,
+		QueueLength:          DefaultQueueLength,
+		Randomizer:           UniformRandomizer{},
+		Gravity:              DefaultGravity,
+		LinesPerLevel:        DefaultLinesPerLevel,
+		LockDelay:            DefaultLockDelay,
+		LockResets:           DefaultLockResets,
+	}
+}
+
+// DefaultBucketConfig returns the configuration of a simple tetris-like game.
+func DefaultBucketConfig() Config {
+	c := DefaultConfig()
+	c.Rows = DefaultBucketGameRows
+	c.Columns = DefaultBucketGameColumns
+	c.NumberPossiblePieces = DefaultBucketNumberPossiblePieces
+	c.PieceMap = DefaultBucketPieceMap
+	c.RotationSystem = ClassicRotation{}
+	return c
+}
+
+// withDefaults returns the configuration with the zero values that stand for
+// a default replaced by the default.
+func (c Config) withDefaults() Config {
+
+	if 0 == c.NumberPossiblePieces {
+		c.NumberPossiblePieces = len(c.PieceMap)
+	}
+	if nil == c.RotationSystem && isSRSPieceMap(c.PieceMap) {
+		c.RotationSystem = DefaultRotationSystem
+	} else if nil == c.RotationSystem {
+		c.RotationSystem = ClassicRotation{}
+	}
+	if nil == c.Randomizer {
+		c.Randomizer = UniformRandomizer{}
+	}
+	if nil == c.Gravity {
+		c.Gravity = DefaultGravity
+	}
+	if 0 == c.SpawnColumn {
+		c.SpawnColumn = c.Columns / 2
+	}
+
+	return c
+}
+
+// isSRSPieceMap reports whether the piece map is SRSPieceMap itself.
+func isSRSPieceMap(piece_map [][][][]int) bool {
+	return len(piece_map) == len(SRSPieceMap) && &piece_map[0] == &SRSPieceMap[0]
+}
+
+// Validate checks that a game can be played with the configuration.
+// Returns:
+// - An error describing the first problem found
+// - nil if the configuration is usable
+func (c Config) Validate() error {
+
+	c = c.withDefaults()
+
+	if c.Rows < 1 || c.Columns < 1 {
+		return fmt.Errorf("invalid config: field of %d rows by %d columns is too small", c.Rows, c.Columns)
+	}
+	if c.NumberPossiblePieces < 1 {
+		return fmt.Errorf("invalid config: no pieces to play")
+	}
+	if c.NumberPossiblePieces > len(c.PieceMap) {
+		return fmt.Errorf("invalid config: %d pieces to play but the piece map has %d", c.NumberPossiblePieces, len(c.PieceMap))
+	}
+	if c.QueueLength < 0 {
+		return fmt.Errorf("invalid config: negative queue length %d", c.QueueLength)
+	}
+	if c.LinesPerLevel < 0 {
+		return fmt.Errorf("invalid config: negative lines per level %d", c.LinesPerLevel)
+	}
+	if c.LockDelay < 0 {
+		return fmt.Errorf("invalid config: negative lock delay %v", c.LockDelay)
+	}
+	if c.LockResets < 0 {
+		return fmt.Errorf("invalid config: negative lock resets %d", c.LockResets)
+	}
+
+	for piece := 0; piece < c.NumberPossiblePieces; piece++ {
+		if err := c.validatePiece(piece); nil != err {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validatePiece checks that the piece has 4 rotations that pieceCollision
+// can read and that it fits inside the field where it comes into play.
+func (c Config) validatePiece(piece int) error {
+
+	if len(c.PieceMap[piece]) < 4 {
+		return fmt.Errorf("invalid config: piece %d has %d rotations, want 4", piece, len(c.PieceMap[piece]))
+	}
+
+	for rotation := 0; rotation < 4; rotation++ {
+		blocks := c.PieceMap[piece][rotation]
+		if len(blocks) < 4 {
+			return fmt.Errorf("invalid config: piece %d rotation %d has %d rows, want 4", piece, rotation, len(blocks))
+		}
+		for i := 0; i < 4; i++ {
+			if len(blocks[i]) < 4 {
+				return fmt.Errorf("invalid config: piece %d rotation %d row %d has %d columns, want 4", piece, rotation, i, len(blocks[i]))
+			}
+		}
+	}
+
+	// GOAL: every block of the spawn rotation is inside the walls
+	count := 0
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if 0 == c.PieceMap[piece][0][i][j] {
+				continue
+			}
+			count++
+
+			row := 1 + i
+			col := c.SpawnColumn + j
+			if row > c.Rows || col < 1 || col > c.Columns {
+				return fmt.Errorf("invalid config: piece %d does not fit in a field of %d rows by %d columns when it comes into play at column %d", piece, c.Rows, c.Columns, c.SpawnColumn)
+			}
+		}
+	}
+	if 0 == count {
+		return fmt.Errorf("invalid config: piece %d has no blocks", piece)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"testing"
+)
+
+func TestDefaultConfigsValid(t *testing.T) {
+
+	if err := DefaultConfig().Validate(); nil != err {
+		t.Errorf("Default config not valid.  %v", err)
+	}
+	if err := DefaultBucketConfig().Validate(); nil != err {
+		t.Errorf("Default bucket config not valid.  %v", err)
+	}
+}
+
+func TestConfigNotValid(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		change func(c *Config)
+	}{
+		{"no rows", func(c *Config) { c.Rows = 0 }},
+		{"negative columns", func(c *Config) { c.Columns = -1 }},
+		{"too many pieces", func(c *Config) { c.NumberPossiblePieces = len(c.PieceMap) + 1 }},
+		{"no piece map", func(c *Config) { c.PieceMap = nil }},
+		{"columns too small for spawn", func(c *Config) { c.Columns = 4 }},
+		{"spawn outside the field", func(c *Config) { c.SpawnColumn = c.Columns }},
+		{"spawn inside the wall", func(c *Config) { c.SpawnColumn = -1 }},
+		{"negative queue", func(c *Config) { c.QueueLength = -1 }},
+		{"negative lock delay", func(c *Config) { c.LockDelay = -1 }},
+		{"negative lock resets", func(c *Config) { c.LockResets = -1 }},
+		{"negative lines per level", func(c *Config) { c.LinesPerLevel = -1 }},
+		{"missing rotation", func(c *Config) {
+			c.PieceMap = [][][][]int{DefaultPieceMap[0][:3]}
+			c.NumberPossiblePieces = 1
+		}},
+		{"narrow piece map", func(c *Config) {
+			c.PieceMap = [][][][]int{{{{1, 1}}, {{1, 1}}, {{1, 1}}, {{1, 1}}}}
+			c.NumberPossiblePieces = 1
+		}},
+		{"empty piece", func(c *Config) {
+			empty := make([][]int, 4)
+			for i := range empty {
+				empty[i] = make([]int, 4)
+			}
+			c.PieceMap = [][][][]int{{empty, empty, empty, empty}}
+			c.NumberPossiblePieces = 1
+		}},
+	}
+
+	for _, test := range tests {
+		config := DefaultConfig()
+		test.change(&config)
+
+		if err := config.Validate(); nil == err {
+			t.Errorf("Config not rejected.  %s", test.name)
+		}
+
+		g, _, _, err := NewSeededGame(config)
+		if nil == err || nil != g {
+			t.Errorf("Game created from invalid config.  %s", test.name)
+		}
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+
+	config := Config{
+		Rows:     DefaultGameRows,
+		Columns:  DefaultGameColumns,
+		PieceMap: DefaultPieceMap,
+	}
+
+	g, err := NewSteppedGame(config)
+	if nil != err {
+		t.Fatalf("Game not created.  %v", err)
+	}
+
+	if g.NumberPossiblePieces != len(DefaultPieceMap) {
+		t.Errorf("Number of pieces not expected.  got: %d  want: %d", g.NumberPossiblePieces, len(DefaultPieceMap))
+	}
+	if g.PiecePosCol != DefaultGameColumns/2 {
+		t.Errorf("Spawn column not expected.  got: %d  want: %d", g.PiecePosCol, DefaultGameColumns/2)
+	}
+	if nil == g.RotationSystem || nil == g.Randomizer || nil == g.Gravity {
+		t.Errorf("Defaults not used.  %+v", g)
+	}
+
+	// CLAIM: the SRS kicks are only the default for the SRS orientations
+	if _, ok := g.RotationSystem.(ClassicRotation); !ok {
+		t.Errorf("Rotation system not expected.  got: %T  want: ClassicRotation", g.RotationSystem)
+	}
+	config.PieceMap = SRSPieceMap
+	g, _ = NewSteppedGame(config)
+	if g.RotationSystem != DefaultRotationSystem {
+		t.Errorf("Rotation system not expected.  got: %T  want: %T", g.RotationSystem, DefaultRotationSystem)
+	}
+}
+
+func TestConfigSpawnColumn(t *testing.T) {
+
+	config := testConfig(1)
+	config.SpawnColumn = 1
+
+	g, err := NewSteppedGame(config)
+	if nil != err {
+		t.Fatalf("Game not created.  %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if g.PiecePosCol != 1 {
+			t.Errorf("Spawn column not expected.  got: %d  want: %d", g.PiecePosCol, 1)
+		}
+		g.Step([]byte{PlayInputHardDrop}, 0)
+	}
+}
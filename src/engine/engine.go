@@ -2,6 +2,7 @@ package engine
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"math/rand"
 	"time"
@@ -16,6 +17,15 @@ const (
 	DefaultBucketGameRows             = 10
 	DefaultBucketGameColumns          = 3
 	DefaultBucketNumberPossiblePieces = 1
+
+	DefaultQueueLength = 5
+
+	DefaultGravityInterval = time.Millisecond * 500
+
+	DefaultLockDelay  = time.Millisecond * 500
+	DefaultLockResets = 15
+
+	DefaultLinesPerLevel = 10
 )
 
 // DOC: Possible states a game can be in
@@ -37,6 +47,10 @@ const (
 	PlayInputRotate
 	PlayInputDrop
 	PlayInputToggleDrop // used for testing
+	PlayInputRotateCCW
+	PlayInputRotate180
+	PlayInputHardDrop
+	PlayInputHold
 )
 
 // DOC: Data structure describing a game
@@ -51,10 +65,43 @@ type Game struct {
 	Field                [][]int
 	ScorePieceCount      int
 	ScoreLineCount       int
+	ScoreSoftDropRows    int
+	ScoreHardDropRows    int
 	GameRows             int
 	GameColumns          int
 	NumberPossiblePieces int
 	PieceMap             [][][][]int
+	RotationSystem       RotationSystem
+	LastKickIndex        int
+	LastKickOffset       Kick
+	LastRotationTurns    int
+	LastMoveRotation     bool
+	HoldPiece            int
+	HoldUsed             bool
+	NextPieces           []int
+	Randomizer           Randomizer
+	LockDelay            time.Duration
+	LockResetLimit       int
+	LockResets           int
+	Level                int
+	LinesPerLevel        int
+	Gravity              GravityCurve
+	Score                int
+	Combo                int
+	BackToBack           bool
+	LastScore            ScoreBreakdown
+	SpawnColumn          int
+	prngSource           *countingSource
+	replay               *Replay
+	lowestRow            int
+	pieceSoftDropRows    int
+	pieceHardDropRows    int
+	pendingEvents        []Event
+	gravityElapsed       time.Duration
+	gravityDisabled      bool
+	lockElapsed          time.Duration
+	lockActive           bool
+	onLock               func(ScoreBreakdown)
 }
 
 // DOC: Mapping from piece and rotation to blocks covered
@@ -283,55 +330,179 @@ var DefaultBucketPieceMap = [][][][]int{
 // NewGame creates a new instance of a tetris game.
 // Returns:
 // - A game struct for the new game
-// - The input channel that player moves will be read from
+// - The input channel that player moves will be read from until the game ends
 // - An output channel that will be sent each state change
 func NewGame() (*Game, chan<- byte, <-chan *Game) {
+	return NewGameWithContext(context.Background())
+}
+
+// NewGameWithContext creates a new instance of a tetris game like NewGame whose
+// main game loop also ends when the context is cancelled.  The output channel
+// is closed when the main game loop ends, so it can be read with range.
+// Player moves are not read after that, so stop sending them once the output
+// channel is closed.
+// Returns:
+// - A game struct for the new game
+// - The input channel that player moves will be read from until the game ends
+// - An output channel that will be sent each state change
+func NewGameWithContext(ctx context.Context) (*Game, chan<- byte, <-chan *Game) {
 
-	seed := time.Now().UTC().UnixNano()
+	config := DefaultConfig()
+	config.Seed = time.Now().UTC().UnixNano()
 
-	return NewSeededGame(seed, DefaultGameRows, DefaultGameColumns, DefaultNumberPossiblePieces, DefaultPieceMap)
+	g, player_input_channel, output_state_channel, _ := newSeededGame(ctx, config, false)
+	return g, player_input_channel, output_state_channel
 }
 
 // NewBucketGame creates a new instance of a simple tetris-like game.
 // Returns:
 // - A game struct for the new game
-// - The input channel that player moves will be read from
+// - The input channel that player moves will be read from until the game ends
 // - An output channel that will be sent each state change
 func NewBucketGame() (*Game, chan<- byte, <-chan *Game) {
 
-	seed := time.Now().UTC().UnixNano()
+	config := DefaultBucketConfig()
+	config.Seed = time.Now().UTC().UnixNano()
 
-	return NewSeededGame(seed, DefaultBucketGameRows, DefaultBucketGameColumns, DefaultBucketNumberPossiblePieces, DefaultBucketPieceMap)
+	g, player_input_channel, output_state_channel, _ := newSeededGame(context.Background(), config, false)
+	return g, player_input_channel, output_state_channel
 }
 
-// NewSeededGame creates a new instance of a game using the the PRNG seed.
-// Inputs:
-// - a seed to be used for the generation of pieces to be played
+// NewSeededGame creates a new instance of a game from the configuration,
+// including the PRNG seed.
 // Returns:
 // - A game struct for the new game
-// - The input channel that player moves will be read from
+// - The input channel that player moves will be read from until the game ends
 // - An output channel that will be sent each state change
-func NewSeededGame(seed int64, rows int, cols int, num_pieces int, piece_map [][][][]int) (*Game, chan<- byte, <-chan *Game) {
+// - An error if the configuration is not valid
+func NewSeededGame(config Config) (*Game, chan<- byte, <-chan *Game, error) {
+
+	if err := config.Validate(); nil != err {
+		return nil, nil, nil, err
+	}
+
+	g, player_input_channel, output_state_channel, _ := newSeededGame(context.Background(), config, false)
+	return g, player_input_channel, output_state_channel, nil
+}
+
+// NewSeededGameWithEvents creates a new instance of a game like NewSeededGame
+// and also returns a channel of the events that change the game.  The events
+// for a change are sent before the state after the change.  Both channels
+// must be read for the game to continue.
+// Returns:
+// - A game struct for the new game
+// - The input channel that player moves will be read from until the game ends
+// - An output channel that will be sent each state change
+// - An output channel that will be sent each event
+// - An error if the configuration is not valid
+func NewSeededGameWithEvents(config Config) (*Game, chan<- byte, <-chan *Game, <-chan Event, error) {
+	return NewSeededGameWithContext(context.Background(), config)
+}
+
+// NewSeededGameWithContext creates a new instance of a game like
+// NewSeededGameWithEvents whose main game loop also ends when the context is
+// cancelled.  Both output channels are closed when the main game loop ends.
+// Returns:
+// - A game struct for the new game
+// - The input channel that player moves will be read from until the game ends
+// - An output channel that will be sent each state change
+// - An output channel that will be sent each event
+// - An error if the configuration is not valid
+func NewSeededGameWithContext(ctx context.Context, config Config) (*Game, chan<- byte, <-chan *Game, <-chan Event, error) {
+
+	if err := config.Validate(); nil != err {
+		return nil, nil, nil, nil, err
+	}
+
+	g, player_input_channel, output_state_channel, output_event_channel := newSeededGame(ctx, config, true)
+	return g, player_input_channel, output_state_channel, output_event_channel, nil
+}
+
+// NewSteppedGame creates a new instance of a game like NewSeededGame that is
+// advanced by calling Step instead of by a main game loop.  The game is
+// already running and the first Step also returns the spawn of the first piece.
+// Returns:
+// - A game struct for the new game
+// - An error if the configuration is not valid
+func NewSteppedGame(config Config) (*Game, error) {
+
+	if err := config.Validate(); nil != err {
+		return nil, err
+	}
+
+	g := newGameState(config)
+	g.State = StateRunning
+
+	return g, nil
+}
+
+// newSeededGame creates a new game from a valid configuration and starts the
+// main game loop, until the context is cancelled, with an event channel only
+// if with_events is set.
+func newSeededGame(ctx context.Context, config Config, with_events bool) (*Game, chan<- byte, <-chan *Game, <-chan Event) {
+	return startGame(ctx, newGameState(config), with_events)
+}
+
+// startGame creates the channels for a game and starts its main game loop,
+// until the context is cancelled, with an event channel only if with_events
+// is set.
+func startGame(ctx context.Context, g *Game, with_events bool) (*Game, chan<- byte, <-chan *Game, <-chan Event) {
+
+	player_input_channel := make(chan byte, 5)
+	output_state_channel := make(chan *Game, 5)
+	var output_event_channel chan Event
+	if with_events {
+		output_event_channel = make(chan Event, 64)
+	}
+
+	// GOAL: Start the main game loop
+	g.MainGameLoop(ctx, nil, player_input_channel, output_state_channel, output_event_channel)
+	return g, player_input_channel, output_state_channel, output_event_channel
+}
+
+// newGameState creates the state of a new game from a valid configuration
+// with an empty field and the first piece in play, without starting the main
+// game loop.
+func newGameState(config Config) *Game {
+
+	config = config.withDefaults()
+
 	g := Game{
-		Seed:          seed,
+		Seed:          config.Seed,
 		State:         StateInitializing,
 		Piece:         1,
 		PieceRotation: 0,
 		PiecePosCol:   4,
 		PiecePosRow:   1,
+		HoldPiece:     -1,
+		Level:         1,
+		Combo:         -1,
 	}
-	g.GameRows = rows
-	g.GameColumns = cols
-	g.NumberPossiblePieces = num_pieces
-	g.PieceMap = piece_map
+	g.GameRows = config.Rows
+	g.GameColumns = config.Columns
+	g.NumberPossiblePieces = config.NumberPossiblePieces
+	g.PieceMap = config.PieceMap
+	g.RotationSystem = config.RotationSystem
+	g.Randomizer = config.Randomizer.Copy()
+	g.SpawnColumn = config.SpawnColumn
+	g.LockDelay = config.LockDelay
+	g.LockResetLimit = config.LockResets
+	g.Gravity = config.Gravity
+	g.LinesPerLevel = config.LinesPerLevel
 
 	g.Field = make([][]int, g.GameRows+2)
 	for i := range g.Field {
 		g.Field[i] = make([]int, g.GameColumns+2)
 	}
 
-	source := rand.NewSource(g.Seed)
-	g.PRNG = rand.New(source)
+	g.prngSource = newCountingSource(g.Seed, 0)
+	g.PRNG = rand.New(g.prngSource)
+
+	// GOAL: fill the queue of upcoming pieces and take the first one
+	g.NextPieces = make([]int, config.QueueLength)
+	for i := range g.NextPieces {
+		g.NextPieces[i] = g.randomPiece()
+	}
 	g.nextPiece()
 
 	for j := 0; j < g.GameColumns+2; j++ {
@@ -346,12 +517,7 @@ func NewSeededGame(seed int64, rows int, cols int, num_pieces int, piece_map [][
 		}
 	}
 
-	player_input_channel := make(chan byte, 5)
-	output_state_channel := make(chan *Game, 5)
-
-	// GOAL: Start the main game loop
-	g.MainGameLoop(player_input_channel, output_state_channel)
-	return &g, player_input_channel, output_state_channel
+	return &g
 }
 
 // CopyOfState returns a copy of the game's current state that is readable
@@ -362,7 +528,8 @@ func NewSeededGame(seed int64, rows int, cols int, num_pieces int, piece_map [][
 // Note:
 //   - The PieceMap is a reference to the current game and not a copy so
 //     changes made to it would change the current game.
-//   - The PRNG is not usable so the copy is not a playable game.
+//   - The PRNG is not usable so the copy is not a playable game, but it can be
+//     saved with Save and then played with LoadGame.
 func (g *Game) CopyOfState() *Game {
 
 	new_copy := Game{
@@ -375,13 +542,50 @@ func (g *Game) CopyOfState() *Game {
 		PiecePosRow:          g.PiecePosRow,
 		ScoreLineCount:       g.ScoreLineCount,
 		ScorePieceCount:      g.ScorePieceCount,
+		ScoreSoftDropRows:    g.ScoreSoftDropRows,
+		ScoreHardDropRows:    g.ScoreHardDropRows,
 		Field:                nil,
 		GameRows:             g.GameRows,
 		GameColumns:          g.GameColumns,
 		NumberPossiblePieces: g.NumberPossiblePieces,
 		PieceMap:             g.PieceMap,
+		RotationSystem:       g.RotationSystem,
+		LastKickIndex:        g.LastKickIndex,
+		LastKickOffset:       g.LastKickOffset,
+		LastRotationTurns:    g.LastRotationTurns,
+		LastMoveRotation:     g.LastMoveRotation,
+		HoldPiece:            g.HoldPiece,
+		HoldUsed:             g.HoldUsed,
+		NextPieces:           nil,
+		Randomizer:           g.Randomizer.Copy(),
+		LockDelay:            g.LockDelay,
+		LockResetLimit:       g.LockResetLimit,
+		LockResets:           g.LockResets,
+		Level:                g.Level,
+		LinesPerLevel:        g.LinesPerLevel,
+		Gravity:              g.Gravity,
+		Score:                g.Score,
+		Combo:                g.Combo,
+		BackToBack:           g.BackToBack,
+		LastScore:            g.LastScore,
+		SpawnColumn:          g.SpawnColumn,
+		lowestRow:            g.lowestRow,
+		pieceSoftDropRows:    g.pieceSoftDropRows,
+		pieceHardDropRows:    g.pieceHardDropRows,
+		gravityElapsed:       g.gravityElapsed,
+		gravityDisabled:      g.gravityDisabled,
+		lockElapsed:          g.lockElapsed,
+		lockActive:           g.lockActive,
 	}
 
+	if nil != g.prngSource {
+		// CLAIM: the copy only needs to know how far the PRNG has got
+		new_copy.prngSource = &countingSource{draws: g.prngSource.draws}
+	}
+
+	new_copy.NextPieces = make([]int, len(g.NextPieces))
+	copy(new_copy.NextPieces, g.NextPieces)
+
 	new_copy.Field = make([][]int, g.GameRows+2)
 	for i := range new_copy.Field {
 		new_copy.Field[i] = make([]int, g.GameColumns+2)
@@ -446,6 +650,7 @@ func (g *Game) GetDebugState() string {
 
 // pieceCollision determines whether a specified piece in the specified position and
 // rotation would collide with any existing blocks on the specfied field.
+// Blocks outside of the field, which a wall kick can ask about, always collide.
 // Returns:
 // - true if there is a collision
 // - false otherwise
@@ -453,6 +658,9 @@ func pieceCollision(g *Game, piece int, rotation int, row int, col int) bool {
 	for i := 0; i < 4; i++ {
 		for j := 0; j < 4; j++ {
 			if 0 != g.PieceMap[piece][rotation][i][j] {
+				if row+i < 0 || row+i >= len(g.Field) || col+j < 0 || col+j >= len(g.Field[row+i]) {
+					return true
+				}
 				if 0 != g.Field[row+i][col+j] {
 					return true
 				}
@@ -464,26 +672,69 @@ func pieceCollision(g *Game, piece int, rotation int, row int, col int) bool {
 	return false
 }
 
-// rotate changes the rotation of the piece only if the rotation would not collide.
-func (g *Game) rotate() {
-
-	if !pieceCollision(g, g.Piece, (g.PieceRotation+1)%4, g.PiecePosRow, g.PiecePosCol) {
-		g.PieceRotation = (g.PieceRotation + 1) % 4
+// rotate turns the piece clockwise by the given number of quarter turns only if
+// the rotation would not collide.  One turn is clockwise, two turns is 180
+// degrees and three turns is counter-clockwise.
+// Each kick offered by the rotation system is tried in order and the first one
+// that does not collide is used and recorded in LastKickIndex and LastKickOffset,
+// and the turns in LastRotationTurns.
+// Returns:
+// - true if the piece was rotated
+// - false otherwise
+func (g *Game) rotate(turns int) bool {
+
+	to := (g.PieceRotation + turns) % 4
+
+	for index, kick := range g.RotationSystem.Kicks(g.Piece, g.PieceRotation, to) {
+		if !pieceCollision(g, g.Piece, to, g.PiecePosRow+kick.Row, g.PiecePosCol+kick.Col) {
+			g.PieceRotation = to
+			g.PiecePosRow += kick.Row
+			g.PiecePosCol += kick.Col
+			g.LastKickIndex = index
+			g.LastKickOffset = kick
+			g.LastRotationTurns = turns % 4
+			g.LastMoveRotation = true
+			g.emit(EventPieceRotated).Kick = kick
+			return true
+		}
 	}
+
+	// CLAIM: every kick collides
+	return false
 }
 
 // moveLeft move the position to the left only if the move would not collide.
-func (g *Game) moveLeft() {
+// Returns:
+// - true if the piece was moved
+// - false otherwise
+func (g *Game) moveLeft() bool {
 	if !pieceCollision(g, g.Piece, g.PieceRotation, g.PiecePosRow, g.PiecePosCol-1) {
 		g.PiecePosCol--
+		g.LastMoveRotation = false
+		g.emit(EventPieceMoved)
+		return true
 	}
+	return false
 }
 
 // moveRight move the position to the right only if the move would not collide.
-func (g *Game) moveRight() {
+// Returns:
+// - true if the piece was moved
+// - false otherwise
+func (g *Game) moveRight() bool {
 	if !pieceCollision(g, g.Piece, g.PieceRotation, g.PiecePosRow, g.PiecePosCol+1) {
 		g.PiecePosCol++
+		g.LastMoveRotation = false
+		g.emit(EventPieceMoved)
+		return true
 	}
+	return false
+}
+
+// landed determines whether the piece is resting on the field and would
+// collide if lowered.
+func (g *Game) landed() bool {
+	return pieceCollision(g, g.Piece, g.PieceRotation, g.PiecePosRow+1, g.PiecePosCol)
 }
 
 // lowerPiece lowers the position by one step only if the move would not collide.
@@ -502,9 +753,82 @@ func (g *Game) lowerPiece() bool {
 	}
 
 	g.PiecePosRow++
+	g.LastMoveRotation = false
+	g.emit(EventPieceMoved)
+
+	if g.lowestRow < g.PiecePosRow {
+		// CLAIM: the piece reached a new lowest row so it earns new lock resets
+		// and a new lock delay
+		g.lowestRow = g.PiecePosRow
+		g.LockResets = 0
+		g.lockElapsed = 0
+	}
+
 	return true
 }
 
+// hardDrop lowers the piece as far as it will go and locks it in place.
+// The number of rows dropped is credited to ScoreHardDropRows.
+func (g *Game) hardDrop() {
+
+	rows := 0
+	for g.lowerPiece() {
+		rows++
+	}
+	g.creditHardDrop(rows)
+
+	g.lockPiece()
+}
+
+// lockPiece places the piece on the field, clears completed rows, scores the
+// piece, including any T-spin, and brings the next piece into play.  The game
+// is over when the piece locked without ever leaving the top row, or after
+// garbage lifted it above the top row.  In a match
+// the lock then sends garbage, and garbage waiting for the game rises under
+// the next piece.
+func (g *Game) lockPiece() {
+
+	spin := g.detectSpin()
+	g.placePiece()
+	g.emit(EventPieceLocked).Spin = spin
+
+	rows := g.clearCompletedRows()
+	if 0 < len(rows) {
+		cleared := g.emit(EventLinesCleared)
+		cleared.Spin = spin
+		cleared.Rows = rows
+	}
+	g.scoreLock(len(rows), spin)
+
+	level := g.Level
+	g.updateLevel()
+	if level != g.Level {
+		g.emit(EventLevelUp)
+	}
+
+	topped_out := g.PiecePosRow <= 1
+	g.nextPiece()
+
+	if topped_out {
+		// CLAIM: game over
+		g.State = StateGameOver
+		g.emit(EventGameOver)
+	}
+
+	// GOAL: let a match send and receive garbage
+	if nil != g.onLock {
+		g.onLock(g.LastScore)
+	}
+}
+
+// updateLevel goes up a level for every LinesPerLevel lines cleared.
+func (g *Game) updateLevel() {
+
+	if 0 < g.LinesPerLevel {
+		g.Level = max(g.Level, 1+g.ScoreLineCount/g.LinesPerLevel)
+	}
+}
+
 // placePiece updates the field to place each block from the piece onto the play field.
 func (g *Game) placePiece() {
 
@@ -517,25 +841,88 @@ func (g *Game) placePiece() {
 	}
 }
 
+// randomPiece picks a new random piece using the game's randomizer.
+func (g *Game) randomPiece() int {
+	return g.Randomizer.Next(g.PRNG, g.NumberPossiblePieces)
+}
+
 // nextPiece updates the game state to have a new piece in play at the top of the field.
+// The piece is taken from the front of NextPieces and a new random piece is
+// added to the back.
 // FIXME: when would this return an error?
 func (g *Game) nextPiece() {
-	// GOAL: pick a new random piece
 
-	x := g.PRNG.Intn(g.NumberPossiblePieces)
+	g.spawnPiece(g.takeNextPiece())
+	g.HoldUsed = false
+	g.ScorePieceCount++
+
+}
+
+// takeNextPiece takes the next piece from the queue and refills the queue.
+// Returns:
+// - the next piece
+func (g *Game) takeNextPiece() int {
+
+	x := g.randomPiece()
+	if 0 < len(g.NextPieces) {
+		x, g.NextPieces = g.NextPieces[0], append(g.NextPieces[1:], x)
+	}
+
+	return x
+}
 
-	g.Piece = int(x)
+// spawnPiece puts the specified piece in play at the top of the field.
+func (g *Game) spawnPiece(piece int) {
 
-	g.PiecePosCol = g.GameColumns / 2
+	g.Piece = piece
+
+	g.PiecePosCol = g.SpawnColumn
 	g.PiecePosRow = 1
 	g.PieceRotation = 0
-	g.ScorePieceCount++
+	g.LastKickIndex = -1
+	g.LastKickOffset = Kick{}
+	g.LastRotationTurns = 0
+	g.LastMoveRotation = false
+	g.LockResets = 0
+	g.lowestRow = g.PiecePosRow
+	g.lockActive = false
+	g.lockElapsed = 0
+
+	g.emit(EventPieceSpawned)
+}
+
+// hold swaps the piece in play with the held piece.  When nothing is held yet
+// the next piece is brought into play, without counting it as another piece
+// played.  Holding is allowed once per piece.
+// Returns:
+// - true if the piece was held
+// - false otherwise
+func (g *Game) hold() bool {
+
+	if g.HoldUsed {
+		return false
+	}
 
+	held := g.HoldPiece
+	g.HoldPiece = g.Piece
+
+	if held < 0 {
+		g.spawnPiece(g.takeNextPiece())
+	} else {
+		g.spawnPiece(held)
+	}
+	g.HoldUsed = true
+
+	return true
 }
 
 // clearCompletedRows finds completed rows in the field, removes them, and drops
 // above rows down.
-func (g *Game) clearCompletedRows() {
+// Returns:
+// - the rows cleared, numbered as they were before any rows dropped down
+func (g *Game) clearCompletedRows() []int {
+
+	var cleared []int
 
 	for i := 1; i < g.GameRows+1; i++ {
 
@@ -553,8 +940,11 @@ func (g *Game) clearCompletedRows() {
 			g.ShiftRowsDown(i)
 
 			g.ScoreLineCount++
+			cleared = append(cleared, i)
 		}
 	}
+
+	return cleared
 }
 
 // ShiftRowsDown drops blocks down by one row, starting at the start_row.
@@ -569,83 +959,86 @@ func (g *Game) ShiftRowsDown(start_row int) {
 	}
 }
 
-// MainGameLoop provides the main game loop logic.
-// Reads player input from channel player_input.
-// Sends game state to channel game_state_ch.
-func (g *Game) MainGameLoop(player_input <-chan byte, game_state_ch chan<- *Game) {
+// MainGameLoop provides the main game loop logic as a real time driver of Step.
+// Reads player input from channel player_input.  Closing it stops the game.
+// Sends game state to channel game_state_ch, except while the game is paused.
+// Sends events to channel event_ch, unless it is nil.
+// Uses the clock to measure time and to wake up when gravity or the lock delay
+// are due, or SystemClock if clock is nil.
+// The loop ends after sending the state of the finished game or when the
+// context is cancelled, and then closes game_state_ch and event_ch.  It does
+// not read player_input after it ends, so a sender should stop sending once
+// game_state_ch is closed or select on a send, since a send blocks when the
+// channel buffer is full.
+func (g *Game) MainGameLoop(ctx context.Context, clock Clock, player_input <-chan byte, game_state_ch chan<- *Game, event_ch chan<- Event) {
+
+	if nil == clock {
+		clock = SystemClock{}
+	}
+
+	// send_events returns false if the context was cancelled while sending.
+	send_events := func(events []Event) bool {
+		if nil != event_ch {
+			for _, e := range events {
+				select {
+				case event_ch <- e:
+				case <-ctx.Done():
+					return false
+				}
+			}
+		}
+		return true
+	}
 
-	// GOAL: Create a channel for a ticker to drop the pieces
-	tickDuration := time.Millisecond * 500 // FIXME: use a global/config for drop speed
-	ticker := time.NewTicker(tickDuration)
+	g.State = StateRunning
+	events := g.takeEvents()
 
-	var key byte
 	go func() {
-		dropPiece := false
-		dropEnabled := true
-		g.State = StateRunning
+		defer close(game_state_ch)
+		if nil != event_ch {
+			defer close(event_ch)
+		}
 
-		for {
-			game_state_ch <- g.CopyOfState()
-			dropPiece = false
+		timer := &wakeTimer{clock: clock}
+		defer timer.stop()
+		last := clock.Now()
 
-			select {
-			case key = <-player_input:
-				switch key {
-				case PlayInputStop:
-					g.State = StateGameOver
-				case PlayInputPause:
-					switch g.State {
-					case StateRunning:
-						g.State = StatePaused
-						key := <- player_input
-						for key != PlayInputPause {
-							key = <- player_input
-						}
-						g.State = StateRunning
-						ticker.Reset(tickDuration)
-					}
-				case PlayInputMoveLeft:
-					if g.State == StateRunning {
-						g.moveLeft()
-					}
-				case PlayInputMoveRight:
-					if g.State == StateRunning {
-						g.moveRight()
-					}
-				case PlayInputRotate:
-					if g.State == StateRunning {
-						g.rotate()
-					}
-				case PlayInputDrop:
-					dropPiece = true
-				case PlayInputToggleDrop:
-					dropEnabled = !dropEnabled
+		for {
+			if !send_events(events) {
+				return
+			}
+			if g.State != StatePaused {
+				select {
+				case game_state_ch <- g.CopyOfState():
+				case <-ctx.Done():
+					return
 				}
+			}
 
-			case <-ticker.C:
-				if dropEnabled && g.State == StateRunning {
-					dropPiece = true
-				}
+			if g.State == StateGameOver {
+				// CLAIM: the final state was sent
+				return
 			}
 
-			if dropPiece {
-				// Lower the piece and check if it collides.
-				able_to_lower := g.lowerPiece()
-				if !able_to_lower {
-					g.placePiece()
-					g.clearCompletedRows()
-
-					if 1 == g.PiecePosRow {
-						// CLAIM: game over
-						g.State = StateGameOver
-					}
-					g.nextPiece()
+			// GOAL: wait for player input or for the game to fall due
+			wake := timer.wake(g.NextDeadline())
+
+			var inputs []byte
+			select {
+			case key, ok := <-player_input:
+				if !ok {
+					player_input = nil
+					key = PlayInputStop
 				}
+				inputs = []byte{key}
+			case <-wake:
+			case <-ctx.Done():
+				return
 			}
 
-			if g.State == StateGameOver {
-				ticker.Stop()
-			}
+			now := clock.Now()
+			events = g.Step(inputs, now.Sub(last))
+			last = now
 		}
 	}()
 }
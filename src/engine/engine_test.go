@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"context"
 	"log"
 	"slices"
 	"testing"
@@ -79,9 +80,109 @@ func TestCreateBucketGame(t *testing.T) {
 	}
 }
 
+// testConfig returns the default configuration with the seed.
+func testConfig(seed int64) Config {
+	config := DefaultConfig()
+	config.Seed = seed
+	return config
+}
+
+// lastState reads states until the channel is closed.
+// Returns:
+// - the last state read
+// - the number of states read
+func lastState(t *testing.T, gameOutput <-chan *Game) (*Game, int) {
+
+	var last *Game
+	count := 0
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case game, ok := <-gameOutput:
+			if !ok {
+				return last, count
+			}
+			last = game
+			count++
+		case <-timeout:
+			t.Fatalf("Game output channel not closed.")
+		}
+	}
+}
+
+// startTestClockedGame starts the main game loop of the game on a manual
+// clock, so pieces only fall when the test advances the clock, with an event
+// channel only if with_events is set.
+func startTestClockedGame(g *Game, with_events bool) (*ManualClock, chan<- byte, <-chan *Game, <-chan Event) {
+
+	clock := NewManualClock(time.Unix(0, 0))
+	gameInput := make(chan byte, 5)
+	gameOutput := make(chan *Game, 5)
+	var gameEvents chan Event
+	if with_events {
+		gameEvents = make(chan Event, 64)
+	}
+
+	g.MainGameLoop(context.Background(), clock, gameInput, gameOutput, gameEvents)
+	return clock, gameInput, gameOutput, gameEvents
+}
+
+func TestContextGameOver(t *testing.T) {
+
+	_, gameInput, gameOutput := NewGameWithContext(context.Background())
+
+	gameInput <- PlayInputStop
+
+	game, count := lastState(t, gameOutput)
+	if 0 == count {
+		t.Fatalf("No states sent.")
+	}
+	if game.State != StateGameOver {
+		t.Errorf("Game not over.  %d", game.State)
+	}
+
+	// GOAL: the finished game sends nothing more
+	gameInput <- PlayInputMoveLeft
+	if _, ok := <-gameOutput; ok {
+		t.Errorf("State sent after game over.")
+	}
+}
+
+func TestContextCancel(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, _, gameOutput := NewGameWithContext(ctx)
+
+	game := <-gameOutput
+	if game.State != StateRunning {
+		t.Errorf("Game not running.  %d", game.State)
+	}
+
+	cancel()
+
+	game, _ = lastState(t, gameOutput)
+	if nil != game && game.State != StateRunning {
+		t.Errorf("Game state not expected.  %d", game.State)
+	}
+}
+
+func TestCloseInput(t *testing.T) {
+
+	_, gameInput, gameOutput := NewGameWithContext(context.Background())
+
+	close(gameInput)
+
+	game, _ := lastState(t, gameOutput)
+	if game.State != StateGameOver {
+		t.Errorf("Game not over.  %d", game.State)
+	}
+}
+
 func TestCompleteRow(t *testing.T) {
 
-	gameRoot, gameInput, gameOutput := NewGame()
+	gameRoot := newGameState(testConfig(1))
+	_, gameInput, gameOutput, _ := startTestClockedGame(gameRoot, false)
 
 	game := <-gameOutput
 
@@ -116,10 +217,11 @@ func TestCompleteRow(t *testing.T) {
 	//  [X 0 0 0 0 X X X X X X X]
 	//  [X X X X X X X X X X X X]
 
+	// DOC: the SRS I piece lies on the second row of its box
 	gameRoot.Piece = 0
 	gameRoot.PieceRotation = 0
 	gameRoot.PiecePosCol = 1
-	gameRoot.PiecePosRow = 17
+	gameRoot.PiecePosRow = 16
 
 	gameRoot.Field[18] = []int{1, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1}
 	//log.Printf("1: %s", gameRoot.GetDebugState())
@@ -149,7 +251,8 @@ func TestCompleteRow(t *testing.T) {
 }
 
 func TestGameOver(t *testing.T) {
-	gameRoot, gameInput, gameOutput := NewGame()
+	gameRoot := newGameState(testConfig(1))
+	_, gameInput, gameOutput, _ := startTestClockedGame(gameRoot, false)
 
 	gameInput <- PlayInputPause
 	game := <-gameOutput
@@ -180,7 +283,8 @@ func TestGameOver(t *testing.T) {
 
 func TestMove(t *testing.T) {
 
-	gameRoot, gameInput, gameOutput := NewGame()
+	gameRoot := newGameState(testConfig(1))
+	clock, gameInput, gameOutput, _ := startTestClockedGame(gameRoot, false)
 
 	game := <-gameOutput
 
@@ -236,6 +340,7 @@ func TestMove(t *testing.T) {
 		t.Errorf("Piece not on right wall.  got: %d  expected: %d", posCol, posColExpected)
 	}
 
+	// DOC: the upright SRS I piece stands in the third column of its box
 	gameRoot.PieceRotation = 1
 
 	for i := 12; i > 0; i-- {
@@ -243,7 +348,7 @@ func TestMove(t *testing.T) {
 		game = <-gameOutput
 	}
 	posCol = game.PiecePosCol
-	posColExpected = 0
+	posColExpected = -1
 
 	if posCol != posColExpected {
 		t.Errorf("Piece not on left wall.  got: %d  expected: %d", posCol, posColExpected)
@@ -254,16 +359,23 @@ func TestMove(t *testing.T) {
 		game = <-gameOutput
 	}
 	posCol = game.PiecePosCol
-	posColExpected = 9
+	posColExpected = 8
 
 	if posCol != posColExpected {
 		t.Errorf("Piece not on right wall.  got: %d  expected: %d", posCol, posColExpected)
 	}
 
+	// GOAL: a gravity tick with the drop toggled off leaves the piece
+	posRowExpected := game.PiecePosRow
+	clock.WaitForWaiter()
+	clock.Advance(DefaultGravityInterval)
 	game = <-gameOutput
 
 	posRow := game.PiecePosRow
-	posRowExpected := posRow + 1
+	if posRow != posRowExpected {
+		t.Errorf("Piece dropped with the drop toggled off.  got: %d  expected: %d", posRow, posRowExpected)
+	}
+	posRowExpected = posRow + 1
 
 	gameInput <- PlayInputDrop
 	game = <-gameOutput
@@ -276,21 +388,25 @@ func TestMove(t *testing.T) {
 
 func TestPauseGame(t *testing.T) {
 
-	_, gameInput, gameOutput := NewGame()
+	clock, gameInput, gameOutput, gameEvents := startTestClockedGame(newGameState(testConfig(1)), true)
 
 	game := <-gameOutput
-	log.Printf("%+v", game)
 
 	if game.State != StateRunning {
 		t.Errorf("Game not running.  %d", game.State)
 	}
 
-	posRow := game.PiecePosRow
-	posRowExpected := posRow
+	posRowExpected := game.PiecePosRow
 
 	gameInput <- PlayInputPause
-	time.Sleep(3 * time.Second)
+	for e := range gameEvents {
+		if EventPaused == e.Type {
+			break
+		}
+	}
 
+	// GOAL: neither time nor input moves a paused piece
+	clock.Advance(3 * time.Second)
 	gameInput <- PlayInputDrop
 
 	gameInput <- PlayInputPause
@@ -301,14 +417,14 @@ func TestPauseGame(t *testing.T) {
 		t.Errorf("Game not running.  %d", game.State)
 	}
 
-	if posRow != posRowExpected {
-		t.Errorf("piece not at expected positioon.  got: %d  want: %d", posRow, posRowExpected)
+	if game.PiecePosRow != posRowExpected {
+		t.Errorf("piece not at expected positioon.  got: %d  want: %d", game.PiecePosRow, posRowExpected)
 	}
 }
 
 func TestRotate(t *testing.T) {
 
-	_, gameInput, gameOutput := NewGame()
+	_, gameInput, gameOutput, _ := startTestClockedGame(newGameState(testConfig(1)), false)
 
 	game := <-gameOutput
 
@@ -322,3 +438,205 @@ func TestRotate(t *testing.T) {
 		game = <-gameOutput
 	}
 }
+
+func TestRotateCounterClockwise(t *testing.T) {
+
+	_, gameInput, gameOutput, _ := startTestClockedGame(newGameState(testConfig(1)), false)
+
+	game := <-gameOutput
+
+	gameInput <- PlayInputToggleDrop
+	game = <-gameOutput
+
+	for i := 0; i < 6; i++ {
+		expected := (4 - i%4) % 4
+		if expected != game.PieceRotation {
+			t.Errorf("Rotation not expected. %d, %d", expected, game.PieceRotation)
+		}
+
+		gameInput <- PlayInputRotateCCW
+		game = <-gameOutput
+	}
+
+	gameInput <- PlayInputRotate180
+	game = <-gameOutput
+
+	expected := (4 - 6%4 + 2) % 4
+	if expected != game.PieceRotation {
+		t.Errorf("Rotation not expected. %d, %d", expected, game.PieceRotation)
+	}
+}
+
+func TestHardDrop(t *testing.T) {
+
+	_, gameInput, gameOutput, _ := startTestClockedGame(newGameState(testConfig(1)), false)
+
+	game := <-gameOutput
+
+	gameInput <- PlayInputToggleDrop
+	game = <-gameOutput
+
+	gameInput <- PlayInputDrop
+	game = <-gameOutput
+
+	if game.ScoreSoftDropRows != 1 {
+		t.Errorf("Soft drop rows not expected.  got: %d  want: %d", game.ScoreSoftDropRows, 1)
+	}
+
+	gameInput <- PlayInputHardDrop
+	game = <-gameOutput
+
+	if game.ScorePieceCount != 2 {
+		t.Errorf("Piece not locked.  got: %d  want: %d", game.ScorePieceCount, 2)
+	}
+
+	if game.ScoreHardDropRows < 14 {
+		t.Errorf("Hard drop rows not expected.  got: %d", game.ScoreHardDropRows)
+	}
+
+	if game.ScoreSoftDropRows != 1 {
+		t.Errorf("Soft drop rows changed.  got: %d  want: %d", game.ScoreSoftDropRows, 1)
+	}
+
+	blocks := 0
+	for i := 1; i < game.GameRows+1; i++ {
+		for j := 1; j < game.GameColumns+1; j++ {
+			blocks += game.Field[i][j]
+		}
+	}
+	if blocks != 4 {
+		t.Errorf("Blocks on field not expected.  got: %d  want: %d\n%s", blocks, 4, game.GetDebugState())
+	}
+}
+
+func TestHold(t *testing.T) {
+
+	_, gameInput, gameOutput, _ := startTestClockedGame(newGameState(testConfig(1)), false)
+
+	game := <-gameOutput
+
+	gameInput <- PlayInputToggleDrop
+	game = <-gameOutput
+
+	if game.HoldPiece != -1 {
+		t.Errorf("Hold not empty.  %d", game.HoldPiece)
+	}
+
+	firstPiece := game.Piece
+	pieceCount := game.ScorePieceCount
+
+	gameInput <- PlayInputMoveLeft
+	game = <-gameOutput
+
+	gameInput <- PlayInputHold
+	game = <-gameOutput
+
+	if game.HoldPiece != firstPiece || !game.HoldUsed {
+		t.Errorf("Piece not held.  got: %d  want: %d", game.HoldPiece, firstPiece)
+	}
+
+	if game.ScorePieceCount != pieceCount {
+		t.Errorf("Held piece counted.  got: %d  want: %d", game.ScorePieceCount, pieceCount)
+	}
+
+	if game.PiecePosCol != game.GameColumns/2 || game.PiecePosRow != 1 {
+		t.Errorf("Next piece not at spawn.  got: %d,%d", game.PiecePosRow, game.PiecePosCol)
+	}
+
+	secondPiece := game.Piece
+
+	// GOAL: a second hold before the piece locks does nothing.
+	gameInput <- PlayInputHold
+	game = <-gameOutput
+
+	if game.HoldPiece != firstPiece || game.Piece != secondPiece {
+		t.Errorf("Hold used twice.  hold: %d  piece: %d", game.HoldPiece, game.Piece)
+	}
+
+	gameInput <- PlayInputHardDrop
+	game = <-gameOutput
+
+	if game.HoldUsed {
+		t.Errorf("Hold not available after lock.")
+	}
+
+	gameInput <- PlayInputHold
+	game = <-gameOutput
+
+	if game.Piece != firstPiece {
+		t.Errorf("Held piece not swapped in.  got: %d  want: %d", game.Piece, firstPiece)
+	}
+}
+
+func TestNextPieces(t *testing.T) {
+
+	config := testConfig(42)
+	queued := newGameState(config)
+	config.QueueLength = 0
+	unqueued := newGameState(config)
+
+	if len(queued.NextPieces) != DefaultQueueLength {
+		t.Fatalf("Queue length not expected.  got: %d  want: %d", len(queued.NextPieces), DefaultQueueLength)
+	}
+
+	copied := queued.CopyOfState()
+	copiedQueue := slices.Clone(queued.NextPieces)
+
+	for i := 0; i < 20; i++ {
+		expected := queued.NextPieces[0]
+
+		if queued.Piece != unqueued.Piece {
+			t.Errorf("Piece sequence changed by the queue.  got: %d  want: %d", queued.Piece, unqueued.Piece)
+		}
+
+		queued.nextPiece()
+		unqueued.nextPiece()
+
+		if queued.Piece != expected {
+			t.Errorf("Piece not taken from the queue.  got: %d  want: %d", queued.Piece, expected)
+		}
+	}
+
+	if !slices.Equal(copied.NextPieces, copiedQueue) {
+		t.Errorf("Copy of state changed with the game.  got: %+v  want: %+v", copied.NextPieces, copiedQueue)
+	}
+}
+
+func TestLockDelay(t *testing.T) {
+
+	lockDelay := 300 * time.Millisecond
+	config := testConfig(1)
+	config.LockDelay = lockDelay
+	gameRoot := newGameState(config)
+
+	// GOAL: rest an I piece on the floor
+	gameRoot.Piece = 0
+	gameRoot.PieceRotation = 0
+	gameRoot.PiecePosCol = 1
+	gameRoot.PiecePosRow = 17
+
+	clock, _, gameOutput, _ := startTestClockedGame(gameRoot, false)
+	game := <-gameOutput
+
+	// GOAL: the next gravity tick finds the piece landed but does not lock it
+	clock.WaitForWaiter()
+	clock.Advance(DefaultGravityInterval)
+	game = <-gameOutput
+
+	if game.ScorePieceCount != 1 {
+		t.Fatalf("Piece locked without a lock delay.  %d", game.ScorePieceCount)
+	}
+
+	deadline, ok := game.NextDeadline()
+	if !ok || deadline != lockDelay {
+		t.Errorf("Deadline not expected.  got: %s  want: %s", deadline, lockDelay)
+	}
+
+	clock.WaitForWaiter()
+	clock.Advance(lockDelay)
+	game = <-gameOutput
+
+	if game.ScorePieceCount != 2 {
+		t.Errorf("Piece not locked after the lock delay.  %d", game.ScorePieceCount)
+	}
+}
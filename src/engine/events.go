@@ -0,0 +1,94 @@
+package engine
+
+// DOC: Kinds of events sent by the main game loop
+type EventType int
+
+const (
+	EventPieceSpawned EventType = iota
+	EventPieceMoved
+	EventPieceRotated
+	EventPieceLocked
+	EventLinesCleared
+	EventLevelUp
+	EventPaused
+	EventResumed
+	EventGameOver
+)
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventPieceSpawned:
+		return "PieceSpawned"
+	case EventPieceMoved:
+		return "PieceMoved"
+	case EventPieceRotated:
+		return "PieceRotated"
+	case EventPieceLocked:
+		return "PieceLocked"
+	case EventLinesCleared:
+		return "LinesCleared"
+	case EventLevelUp:
+		return "LevelUp"
+	case EventPaused:
+		return "Paused"
+	case EventResumed:
+		return "Resumed"
+	case EventGameOver:
+		return "GameOver"
+	}
+	return "Unknown"
+}
+
+// DOC: An Event describes one change to a game.  Every event has the level
+// and, except Paused and Resumed, the piece in play and its position at the
+// time of the event.
+//   - PieceRotated also has the kick used.
+//   - PieceLocked and LinesCleared also have the spin the piece was placed with.
+//   - LinesCleared also has the field rows that were cleared, numbered as they
+//     were before the rows above dropped down.
+//   - For LevelUp the level is the new level.
+type Event struct {
+	Type          EventType
+	Piece         int
+	PieceRotation int
+	PiecePosRow   int
+	PiecePosCol   int
+	Kick          Kick
+	Spin          SpinType
+	Rows          []int
+	Level         int
+}
+
+// emit records an event about the piece in play to be returned by Step.
+func (g *Game) emit(event_type EventType) *Event {
+
+	g.pendingEvents = append(g.pendingEvents, Event{
+		Type:          event_type,
+		Piece:         g.Piece,
+		PieceRotation: g.PieceRotation,
+		PiecePosRow:   g.PiecePosRow,
+		PiecePosCol:   g.PiecePosCol,
+		Level:         g.Level,
+	})
+
+	return &g.pendingEvents[len(g.pendingEvents)-1]
+}
+
+// emitGameEvent records an event about the game as a whole, rather than the
+// piece in play, to be returned by Step.
+func (g *Game) emitGameEvent(event_type EventType) {
+	g.pendingEvents = append(g.pendingEvents, Event{
+		Type:  event_type,
+		Level: g.Level,
+	})
+}
+
+// takeEvents returns the recorded events and forgets them.
+func (g *Game) takeEvents() []Event {
+
+	events := g.pendingEvents
+	g.pendingEvents = nil
+
+	return events
+}
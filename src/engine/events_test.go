@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+// drainEvents returns the events already sent on the channel, stopping when
+// it is empty or closed.
+func drainEvents(events <-chan Event) []Event {
+	var got []Event
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return got
+			}
+			got = append(got, e)
+		default:
+			return got
+		}
+	}
+}
+
+// eventTypes returns the type of each event.
+func eventTypes(events []Event) []EventType {
+	var types []EventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestEvents(t *testing.T) {
+
+	gameRoot, gameInput, gameOutput, gameEvents, err := NewSeededGameWithEvents(testConfig(1))
+	if nil != err {
+		t.Fatalf("Game not created.  %v", err)
+	}
+
+	game := <-gameOutput
+	got := eventTypes(drainEvents(gameEvents))
+	want := []EventType{EventPieceSpawned}
+	if !slices.Equal(got, want) {
+		t.Errorf("Events not expected.  got: %v  want: %v", got, want)
+	}
+
+	gameInput <- PlayInputToggleDrop
+	game = <-gameOutput
+	drainEvents(gameEvents)
+
+	gameInput <- PlayInputPause
+	if e := <-gameEvents; e.Type != EventPaused {
+		t.Fatalf("Event not expected.  got: %s  want: %s", e.Type, EventPaused)
+	}
+
+	// GOAL: lay an I piece on the floor next to a nearly complete row
+	gameRoot.Piece = 0
+	gameRoot.PieceRotation = 0
+	gameRoot.PiecePosCol = 1
+	gameRoot.PiecePosRow = 17
+	gameRoot.Field[18] = []int{1, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1}
+
+	gameInput <- PlayInputPause
+	game = <-gameOutput
+	got = eventTypes(drainEvents(gameEvents))
+	want = []EventType{EventResumed}
+	if !slices.Equal(got, want) {
+		t.Errorf("Events not expected.  got: %v  want: %v", got, want)
+	}
+
+	gameInput <- PlayInputHardDrop
+	game = <-gameOutput
+	events := drainEvents(gameEvents)
+	got = eventTypes(events)
+	want = []EventType{EventPieceLocked, EventLinesCleared, EventPieceSpawned}
+	if !slices.Equal(got, want) {
+		t.Fatalf("Events not expected.  got: %v  want: %v", got, want)
+	}
+	if !slices.Equal(events[1].Rows, []int{18}) {
+		t.Errorf("Cleared rows not expected.  got: %v  want: %v", events[1].Rows, []int{18})
+	}
+	if events[2].Piece != game.Piece {
+		t.Errorf("Spawned piece not expected.  got: %d  want: %d", events[2].Piece, game.Piece)
+	}
+
+	gameInput <- PlayInputRotate
+	game = <-gameOutput
+	got = eventTypes(drainEvents(gameEvents))
+	want = []EventType{EventPieceRotated}
+	if !slices.Equal(got, want) {
+		t.Errorf("Events not expected.  got: %v  want: %v", got, want)
+	}
+
+	gameInput <- PlayInputStop
+	game = <-gameOutput
+	got = eventTypes(drainEvents(gameEvents))
+	want = []EventType{EventGameOver}
+	if !slices.Equal(got, want) {
+		t.Errorf("Events not expected.  got: %v  want: %v", got, want)
+	}
+}
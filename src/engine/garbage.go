@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// InsertGarbage pushes the stack up by the number of rows and fills the rows
+// at the bottom with garbage, blocks in every column but the hole column,
+// numbered from 1 like the field.  The piece in play moves up only as far as
+// it must to stay clear of the stack.  The game is over when blocks are
+// pushed out of the top of the field or the piece in play cannot move up
+// far enough.  Like the changes a match makes, inserted garbage is not part
+// of a replay.
+// Returns:
+// - an error if the game is over, the number of rows is negative or the hole
+// is not inside the walls
+func (g *Game) InsertGarbage(rows int, hole int) error {
+
+	if StateGameOver == g.State {
+		return fmt.Errorf("cannot insert garbage: the game is over")
+	}
+	if rows < 0 {
+		return fmt.Errorf("cannot insert garbage: %d rows", rows)
+	}
+	if hole < 1 || hole > g.GameColumns {
+		return fmt.Errorf("cannot insert garbage: hole column %d is outside the walls", hole)
+	}
+
+	g.insertGarbage(rows, hole)
+
+	return nil
+}
+
+// RandomGarbageHole picks the hole column for garbage in a field of the number
+// of columns with the PRNG.  A seeded PRNG gives the same holes every time.
+func RandomGarbageHole(prng *rand.Rand, columns int) int {
+	return 1 + prng.Intn(columns)
+}
+
+// insertGarbage pushes the stack up and lifts the piece in play like
+// InsertGarbage, without checking its arguments.
+func (g *Game) insertGarbage(rows int, hole int) {
+
+	// GOAL: push the blocks of the field up and fill the rows at the bottom
+	topped_out := false
+	for i := 1; i < rows+1 && i < g.GameRows+1; i++ {
+		for j := 1; j < g.GameColumns+1; j++ {
+			if 0 != g.Field[i][j] {
+				topped_out = true
+			}
+		}
+	}
+	for i := 1; i < g.GameRows+1; i++ {
+		for j := 1; j < g.GameColumns+1; j++ {
+			if i+rows < g.GameRows+1 {
+				g.Field[i][j] = g.Field[i+rows][j]
+			} else if j == hole {
+				g.Field[i][j] = 0
+			} else {
+				g.Field[i][j] = 1
+			}
+		}
+	}
+
+	// GOAL: move the piece in play up until it is clear of the stack
+	lifted := 0
+	for pieceCollision(g, g.Piece, g.PieceRotation, g.PiecePosRow, g.PiecePosCol) && lifted < rows {
+		g.PiecePosRow--
+		lifted++
+	}
+	if 0 < lifted {
+		g.lowestRow -= lifted
+		g.emit(EventPieceMoved)
+	}
+
+	if topped_out || pieceCollision(g, g.Piece, g.PieceRotation, g.PiecePosRow, g.PiecePosCol) {
+		// CLAIM: game over
+		g.State = StateGameOver
+		g.lockActive = false
+		g.emitGameEvent(EventGameOver)
+	}
+}
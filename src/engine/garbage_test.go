@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestInsertGarbage(t *testing.T) {
+
+	g := newTestSteppedGame(t, 1, time.Second, DefaultLockDelay)
+	g.Field[g.GameRows][1] = 1
+	row := g.PiecePosRow
+
+	if err := g.InsertGarbage(2, 3); nil != err {
+		t.Fatalf("Garbage not inserted.  %v", err)
+	}
+
+	if 0 == g.Field[g.GameRows-2][1] {
+		t.Errorf("Stack not pushed up.\n%s", g.GetDebugState())
+	}
+	for i := g.GameRows - 1; i < g.GameRows+1; i++ {
+		for j := 1; j < g.GameColumns+1; j++ {
+			if (3 == j) != (0 == g.Field[i][j]) {
+				t.Fatalf("Garbage row not expected.\n%s", g.GetDebugState())
+			}
+		}
+	}
+	if row != g.PiecePosRow {
+		t.Errorf("Piece clear of the stack moved.  got: %d  want: %d", g.PiecePosRow, row)
+	}
+	if StateRunning != g.State {
+		t.Errorf("Game not running.  state: %v", g.State)
+	}
+}
+
+func TestInsertGarbageLiftsPiece(t *testing.T) {
+
+	g := newTestSteppedGame(t, 1, time.Second, DefaultLockDelay)
+
+	// GOAL: land the piece on the floor
+	for g.lowerPiece() {
+	}
+	row := g.PiecePosRow
+
+	if err := g.InsertGarbage(3, 1); nil != err {
+		t.Fatalf("Garbage not inserted.  %v", err)
+	}
+	if row-3 != g.PiecePosRow {
+		t.Errorf("Piece not lifted.  got: %d  want: %d", g.PiecePosRow, row-3)
+	}
+
+	events := eventTypes(g.Step(nil, 0))
+	if !slices.Contains(events, EventPieceMoved) {
+		t.Errorf("Events not expected.  %v", events)
+	}
+}
+
+func TestInsertGarbageLiftedPieceLocks(t *testing.T) {
+
+	// GOAL: an I piece in the top row with a stack two rows below it
+	g := newTestSteppedGame(t, 1, time.Second, DefaultLockDelay)
+	g.Piece = 0
+	g.PieceRotation = 0
+	for j := 1; j < g.GameColumns; j++ {
+		g.Field[4][j] = 1
+	}
+
+	if err := g.InsertGarbage(2, 1); nil != err {
+		t.Fatalf("Garbage not inserted.  %v", err)
+	}
+	if 0 != g.PiecePosRow || StateRunning != g.State {
+		t.Fatalf("Piece not lifted above the top row.\n%s", g.GetDebugState())
+	}
+
+	g.Step([]byte{PlayInputHardDrop}, 0)
+	if StateGameOver != g.State {
+		t.Errorf("Game not over after the lifted piece locked.\n%s", g.GetDebugState())
+	}
+}
+
+func TestInsertGarbageTopOut(t *testing.T) {
+
+	// GOAL: blocks pushed out of the top of the field
+	g := newTestSteppedGame(t, 1, time.Second, DefaultLockDelay)
+	g.Field[1][g.GameColumns] = 1
+	if err := g.InsertGarbage(1, 1); nil != err {
+		t.Fatalf("Garbage not inserted.  %v", err)
+	}
+	if StateGameOver != g.State {
+		t.Errorf("Game not over.  state: %v", g.State)
+	}
+	if events := eventTypes(g.Step(nil, 0)); !slices.Contains(events, EventGameOver) {
+		t.Errorf("Events not expected.  %v", events)
+	}
+
+	// GOAL: a stack right below the piece in play pushes it into the top wall
+	g = newTestSteppedGame(t, 1, time.Second, DefaultLockDelay)
+	for i := 3; i < g.GameRows+1; i++ {
+		for j := 1; j < g.GameColumns; j++ {
+			g.Field[i][j] = 1
+		}
+	}
+	if err := g.InsertGarbage(2, g.GameColumns); nil != err {
+		t.Fatalf("Garbage not inserted.  %v", err)
+	}
+	if StateGameOver != g.State {
+		t.Errorf("Game not over.\n%s", g.GetDebugState())
+	}
+}
+
+func TestInsertGarbageNotValid(t *testing.T) {
+
+	g := newTestSteppedGame(t, 1, time.Second, DefaultLockDelay)
+
+	for _, test := range []struct{ rows, hole int }{{-1, 1}, {1, 0}, {1, g.GameColumns + 1}} {
+		if err := g.InsertGarbage(test.rows, test.hole); nil == err {
+			t.Errorf("Garbage not rejected.  %+v", test)
+		}
+	}
+
+	g.Step([]byte{PlayInputStop}, 0)
+	if err := g.InsertGarbage(1, 1); nil == err {
+		t.Errorf("Garbage inserted after the game is over.")
+	}
+}
+
+func TestRandomGarbageHole(t *testing.T) {
+
+	first := rand.New(rand.NewSource(7))
+	again := rand.New(rand.NewSource(7))
+
+	for i := 0; i < 100; i++ {
+		hole := RandomGarbageHole(first, 10)
+		if hole < 1 || hole > 10 {
+			t.Fatalf("Hole outside the walls.  %d", hole)
+		}
+		if other := RandomGarbageHole(again, 10); other != hole {
+			t.Fatalf("Holes not the same.  got: %d  want: %d", other, hole)
+		}
+	}
+}
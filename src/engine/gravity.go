@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"math"
+	"time"
+)
+
+// DOC: A GravityCurve gives how long a piece takes to fall one row at each
+// level.  Levels start at 1.  A game treats any interval shorter than
+// MinimumGravityInterval as MinimumGravityInterval.
+type GravityCurve interface {
+	Interval(level int) time.Duration
+}
+
+// DOC: The shortest interval between gravity drops.  Faster curves are
+// clamped so the main game loop is not flooded with ticks.
+const MinimumGravityInterval = time.Millisecond
+
+// GuidelineGravity is the curve from the Tetris guideline where a row takes
+// (0.8 - (level-1) * 0.007) ^ (level-1) seconds.  Level1 scales the curve to
+// take that long at level 1, zero for the one second of the guideline.
+type GuidelineGravity struct {
+	Level1 time.Duration
+}
+
+// DOC: The gravity used by NewGame, the guideline curve starting from the
+// speed pieces have always fallen at on level 1.
+var DefaultGravity GravityCurve = GuidelineGravity{Level1: DefaultGravityInterval}
+
+// Interval returns the time to fall one row at the level.
+func (c GuidelineGravity) Interval(level int) time.Duration {
+
+	if level < 1 {
+		level = 1
+	}
+
+	level1 := c.Level1
+	if 0 == level1 {
+		level1 = time.Second
+	}
+
+	scale := math.Pow(0.8-float64(level-1)*0.007, float64(level-1))
+
+	return clampGravity(time.Duration(scale * float64(level1)))
+}
+
+// DOC: Frames per row for each NES level and the NES frame rate.
+var nesGravityFrames = []int{48, 43, 38, 33, 28, 23, 18, 13, 8, 6, 5, 5, 5, 4, 4, 4, 3, 3, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1}
+
+const nesFramesPerSecond = 60.0988
+
+// NESGravity is the frame table of NES Tetris.  Level 1 is NES level 0 and
+// every level past the end of the table uses the last entry.
+type NESGravity struct{}
+
+// Interval returns the time to fall one row at the level.
+func (NESGravity) Interval(level int) time.Duration {
+
+	index := min(max(level-1, 0), len(nesGravityFrames)-1)
+	seconds := float64(nesGravityFrames[index]) / nesFramesPerSecond
+
+	return clampGravity(time.Duration(seconds * float64(time.Second)))
+}
+
+// TableGravity is a custom curve.  The first entry is level 1 and every level
+// past the end of the table uses the last entry.
+type TableGravity []time.Duration
+
+// Interval returns the time to fall one row at the level.
+func (t TableGravity) Interval(level int) time.Duration {
+
+	if 0 == len(t) {
+		return DefaultGravity.Interval(level)
+	}
+
+	index := min(max(level-1, 0), len(t)-1)
+
+	return clampGravity(t[index])
+}
+
+// clampGravity keeps an interval from being shorter than MinimumGravityInterval.
+func clampGravity(d time.Duration) time.Duration {
+	return max(d, MinimumGravityInterval)
+}
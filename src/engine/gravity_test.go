@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGravityCurves(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		curve    GravityCurve
+		level    int
+		expected time.Duration
+	}{
+		{"guideline level 1", GuidelineGravity{}, 1, time.Second},
+		{"guideline level 2", GuidelineGravity{}, 2, 793 * time.Millisecond},
+		{"default level 1", DefaultGravity, 1, DefaultGravityInterval},
+		{"default level 2", DefaultGravity, 2, 396 * time.Millisecond},
+		{"guideline level 30", GuidelineGravity{}, 30, MinimumGravityInterval},
+		{"nes level 1", NESGravity{}, 1, 798 * time.Millisecond},
+		{"nes level 40", NESGravity{}, 40, 16 * time.Millisecond},
+		{"table level 1", TableGravity{300 * time.Millisecond, 200 * time.Millisecond}, 1, 300 * time.Millisecond},
+		{"table past end", TableGravity{300 * time.Millisecond, 200 * time.Millisecond}, 9, 200 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		got := test.curve.Interval(test.level).Truncate(time.Millisecond)
+		if got != test.expected {
+			t.Errorf("Interval not expected.  %s  got: %s  want: %s", test.name, got, test.expected)
+		}
+	}
+}
+
+func TestLevelProgression(t *testing.T) {
+
+	g := newGameState(testConfig(1))
+	g.LinesPerLevel = DefaultLinesPerLevel
+
+	if g.Level != 1 {
+		t.Errorf("Game did not start on level 1.  %d", g.Level)
+	}
+
+	// GOAL: clear four full rows with the I piece lying on top of them
+	for i := 15; i < 19; i++ {
+		for j := 1; j < 11; j++ {
+			g.Field[i][j] = 1
+		}
+	}
+	g.ScoreLineCount = 8
+	g.Piece = 0
+	g.PieceRotation = 0
+	g.PiecePosCol = 1
+	g.PiecePosRow = 14
+
+	g.lockPiece()
+
+	if g.ScoreLineCount != 12 {
+		t.Errorf("Lines not cleared.  got: %d  want: %d", g.ScoreLineCount, 12)
+	}
+
+	if g.Level != 2 {
+		t.Errorf("Level not expected.  got: %d  want: %d", g.Level, 2)
+	}
+}
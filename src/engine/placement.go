@@ -0,0 +1,206 @@
+package engine
+
+import (
+	"slices"
+)
+
+// DOC: A Placement is a place a piece can lock in, the spin it locks with, and
+// a shortest sequence of player inputs that takes the piece there and locks it.
+type Placement struct {
+	Piece    int
+	Rotation int
+	Row      int
+	Col      int
+	Spin     SpinType
+	Path     []byte
+}
+
+// DOC: The moves tried from every position and the change in rotation, row
+// and column for each.  Rotations try the kicks of the rotation system.
+var placementMoves = []struct {
+	input byte
+	turns int
+	row   int
+	col   int
+}{
+	{PlayInputMoveLeft, 0, 0, -1},
+	{PlayInputMoveRight, 0, 0, 1},
+	{PlayInputDrop, 0, 1, 0},
+	{PlayInputRotate, 1, 0, 0},
+	{PlayInputRotateCCW, 3, 0, 0},
+	{PlayInputRotate180, 2, 0, 0},
+}
+
+// DOC: placementNode is a position the search reached, how it got there, and
+// whether the last move was a rotation and whether it used the kick that
+// makes a full T-spin.
+type placementNode struct {
+	rotation  int
+	row       int
+	col       int
+	rotated   bool
+	full_kick bool
+	parent    int
+	input     byte
+}
+
+// Placements finds every distinct place the piece in play can lock in from
+// where it is now.  The search tries every move, soft drop and rotation,
+// with the kicks of the rotation system, so placements reached by tucks and
+// spins are found.  Placements are distinct by the cells the piece covers
+// and the spin it locks with.  Gravity and the lock delay are not considered
+// so the paths assume the inputs are given before the piece falls or locks.
+// Returns:
+// - the placements, each with a shortest path that ends in a hard drop
+func (g *Game) Placements() []Placement {
+
+	start := placementNode{
+		rotation:  g.PieceRotation,
+		row:       g.PiecePosRow,
+		col:       g.PiecePosCol,
+		rotated:   g.LastMoveRotation,
+		full_kick: isFullKick(g.LastRotationTurns, g.LastKickIndex),
+	}
+
+	return g.searchPlacements(g.Piece, start)
+}
+
+// SpawnPlacements finds every distinct place the piece can lock in after it
+// spawns, like Placements does for the piece in play.  It is meant for the
+// held piece and the next pieces.
+// Returns:
+// - the placements, each with a shortest path from the spawn position that
+// ends in a hard drop
+// - nil if the piece is not in the piece map or collides when it spawns
+func (g *Game) SpawnPlacements(piece int) []Placement {
+
+	if piece < 0 || piece >= len(g.PieceMap) {
+		return nil
+	}
+
+	start := placementNode{rotation: 0, row: 1, col: g.SpawnColumn}
+	return g.searchPlacements(piece, start)
+}
+
+// searchPlacements does a breadth first search over the rotation, row and
+// column of the piece from the start.  For T shaped pieces whether the last
+// move was a rotation is part of the state, since it decides the spin.
+// Returns:
+// - a placement for every distinct set of cells and spin the piece can lock in
+func (g *Game) searchPlacements(piece int, start placementNode) []Placement {
+
+	if pieceCollision(g, piece, start.rotation, start.row, start.col) {
+		return nil
+	}
+
+	_, _, _, _, is_t := g.tShape(piece, 0)
+	if !is_t {
+		start.rotated = false
+	}
+
+	// GOAL: number every state a piece could be in, from 3 cells left of and
+	// above the field to inside its far walls, so a spin reached with the
+	// full kick is not lost to the same place reached with another kick
+	rows := len(g.Field) + 3
+	cols := len(g.Field[0]) + 3
+	index := func(n placementNode) int {
+		i := ((n.rotation*rows+n.row+3)*cols + n.col + 3) * 4
+		if n.rotated {
+			i++
+		}
+		if n.full_kick {
+			i += 2
+		}
+		return i
+	}
+
+	visited := make([]bool, 4*rows*cols*4)
+	start.parent = -1
+	nodes := []placementNode{start}
+	visited[index(start)] = true
+
+	var placements []Placement
+	locked := map[string]bool{}
+
+	for n := 0; n < len(nodes); n++ {
+		node := nodes[n]
+
+		if pieceCollision(g, piece, node.rotation, node.row+1, node.col) {
+			// CLAIM: the piece is landed and a hard drop locks it here
+			spin := g.spinAt(piece, node.rotation, node.row, node.col, node.rotated, node.full_kick)
+			key := g.placementKey(piece, node.rotation, node.row, node.col, spin)
+			if !locked[key] {
+				locked[key] = true
+
+				path := []byte{PlayInputHardDrop}
+				for i := n; 0 < i; i = nodes[i].parent {
+					path = append(path, nodes[i].input)
+				}
+				slices.Reverse(path)
+
+				placements = append(placements, Placement{
+					Piece:    piece,
+					Rotation: node.rotation,
+					Row:      node.row,
+					Col:      node.col,
+					Spin:     spin,
+					Path:     path,
+				})
+			}
+		}
+
+		for _, move := range placementMoves {
+			next := placementNode{parent: n, input: move.input}
+			if 0 == move.turns {
+				next.rotation = node.rotation
+				next.row = node.row + move.row
+				next.col = node.col + move.col
+				if pieceCollision(g, piece, next.rotation, next.row, next.col) {
+					continue
+				}
+			} else {
+				// GOAL: turn the piece with the first kick that fits, like rotate does
+				to := (node.rotation + move.turns) % 4
+				found := false
+				for kick_index, kick := range g.RotationSystem.Kicks(piece, node.rotation, to) {
+					if !pieceCollision(g, piece, to, node.row+kick.Row, node.col+kick.Col) {
+						next.rotation = to
+						next.row = node.row + kick.Row
+						next.col = node.col + kick.Col
+						next.rotated = is_t
+						next.full_kick = isFullKick(move.turns, kick_index)
+						found = true
+						break
+					}
+				}
+				if !found {
+					continue
+				}
+			}
+
+			if !visited[index(next)] {
+				visited[index(next)] = true
+				nodes = append(nodes, next)
+			}
+		}
+	}
+
+	return placements
+}
+
+// placementKey describes the field cells the piece covers at the position
+// and the spin, so placements that lock the same way are found once.
+func (g *Game) placementKey(piece int, rotation int, row int, col int, spin SpinType) string {
+
+	key := []byte{byte(spin)}
+	blocks := g.PieceMap[piece][rotation]
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if 0 != blocks[i][j] {
+				key = append(key, byte(row+i), byte(col+j))
+			}
+		}
+	}
+
+	return string(key)
+}
@@ -0,0 +1,204 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlacementsOPiece(t *testing.T) {
+
+	g := newTestSteppedGame(t, 1, time.Second, DefaultLockDelay)
+	g.spawnPiece(3)
+
+	placements := g.Placements()
+	if len(placements) != g.GameColumns-1 {
+		t.Fatalf("Number of placements not expected.  got: %d  want: %d", len(placements), g.GameColumns-1)
+	}
+
+	for _, p := range placements {
+		if p.Row != g.GameRows-1 || p.Spin != SpinNone {
+			t.Errorf("O piece placement not expected.  %+v", p)
+		}
+	}
+}
+
+func TestPlacementsTuck(t *testing.T) {
+
+	g := newTestSteppedGame(t, 1, time.Second, DefaultLockDelay)
+
+	// GOAL: a roof over the left of the field that an O piece can only
+	// reach by dropping beside it and sliding under
+	for j := 1; j < 5; j++ {
+		g.Field[16][j] = 1
+	}
+	g.spawnPiece(3)
+
+	for _, p := range g.Placements() {
+		if p.Row == g.GameRows-1 && p.Col+1 < 4 {
+			g.Step(p.Path, 0)
+			if 0 == g.Field[g.GameRows][p.Col+1] || 0 == g.Field[g.GameRows-1][p.Col+2] {
+				t.Errorf("Path did not tuck the piece.  %+v\n%s", p, g.GetDebugState())
+			}
+			return
+		}
+	}
+
+	t.Errorf("Tuck under the roof not found.")
+}
+
+func TestPlacementsTSpin(t *testing.T) {
+
+	g := newTestSteppedGame(t, 1, time.Second, DefaultLockDelay)
+
+	// GOAL: make the bottom of the field look like:
+	//  [X 0 0 0 X 0 0 0 0 0 0 X]
+	//  [X X X 0 0 0 X X X X X X]
+	//  [X X X X 0 X X X X X X X]
+	for j := 1; j < 11; j++ {
+		g.Field[17][j] = 1
+		g.Field[18][j] = 1
+	}
+	g.Field[16][4] = 1
+	g.Field[17][4] = 0
+	g.Field[17][5] = 0
+	g.Field[17][6] = 0
+	g.Field[18][5] = 0
+	g.spawnPiece(5)
+
+	for _, p := range g.Placements() {
+		if SpinFull == p.Spin && 16 == p.Row {
+			g.Step(p.Path, 0)
+			if g.LastScore.Spin != SpinFull || g.LastScore.Lines != 2 {
+				t.Errorf("Path did not T-spin double.  %+v  score: %+v\n%s", p, g.LastScore, g.GetDebugState())
+			}
+			return
+		}
+	}
+
+	t.Errorf("T-spin double not found.")
+}
+
+func TestPlacementsTSpinTriple(t *testing.T) {
+
+	g := newTestSteppedGame(t, 1, time.Second, DefaultLockDelay)
+
+	// GOAL: make the bottom of the field look like:
+	//  [X 0 0 0 0 0 0 0 X 0 0 X]
+	//  [X 0 0 0 0 0 0 0 0 0 0 X]
+	//  [X X X X X X X X 0 X X X]
+	//  [X X X X X X X 0 0 X X X]
+	//  [X X X X X X X X 0 X X X]
+	// so the T piece only gets in with the fifth kick of a turn
+	g.Field[14][8] = 1
+	for i := 16; i < 19; i++ {
+		for j := 1; j < 11; j++ {
+			g.Field[i][j] = 1
+		}
+		g.Field[i][8] = 0
+	}
+	g.Field[17][7] = 0
+	g.spawnPiece(5)
+
+	for _, p := range g.Placements() {
+		if 3 == p.Rotation && 16 == p.Row && 7 == p.Col {
+			g.Step(p.Path, 0)
+			if g.LastScore.Spin != SpinFull || g.LastScore.Lines != 3 {
+				t.Errorf("Path did not T-spin triple.  %+v  score: %+v\n%s", p, g.LastScore, g.GetDebugState())
+			}
+			return
+		}
+	}
+
+	t.Errorf("T-spin triple not found.")
+}
+
+func TestPlacementsFullKick(t *testing.T) {
+
+	g := newTestSteppedGame(t, 1, time.Second, DefaultLockDelay)
+
+	// GOAL: make the bottom of the field look like:
+	//  [X 0 0 0 0 X 0 0 X 0 0 X]
+	//  [X 0 0 0 0 0 0 0 0 0 0 X]
+	//  [X X X X X X X X 0 X X X]
+	//  [X X X X X X X X 0 0 X X]
+	//  [X X X X X X X X 0 0 X X]
+	// where the T piece turns into the slot, which has an open front corner,
+	// both with the fifth kick, a full T-spin, and with another kick
+	g.Field[14][5] = 1
+	g.Field[14][8] = 1
+	for i := 16; i < 19; i++ {
+		for j := 1; j < 11; j++ {
+			g.Field[i][j] = 1
+		}
+		g.Field[i][8] = 0
+	}
+	g.Field[17][9] = 0
+	g.Field[18][9] = 0
+	g.spawnPiece(5)
+
+	for _, p := range g.Placements() {
+		if SpinFull == p.Spin && 1 == p.Rotation && 16 == p.Row && 7 == p.Col {
+			g.Step(p.Path, 0)
+			if g.LastScore.Spin != SpinFull {
+				t.Errorf("Path did not T-spin.  %+v  score: %+v\n%s", p, g.LastScore, g.GetDebugState())
+			}
+			return
+		}
+	}
+
+	t.Errorf("Full T-spin not found.")
+}
+
+func TestPlacementsPathsLock(t *testing.T) {
+
+	for _, piece := range []int{0, 1, 2, 3, 4, 5, 6} {
+		g := newTestSteppedGame(t, 1, time.Second, DefaultLockDelay)
+		g.spawnPiece(piece)
+
+		placements := g.Placements()
+		spawned := g.SpawnPlacements(piece)
+		if len(placements) != len(spawned) {
+			t.Errorf("Spawn placements not expected.  piece: %d  got: %d  want: %d", piece, len(spawned), len(placements))
+		}
+
+		for _, p := range placements {
+			played := newTestSteppedGame(t, 1, time.Second, DefaultLockDelay)
+			played.spawnPiece(piece)
+			played.Step(p.Path, 0)
+
+			if played.ScorePieceCount == g.ScorePieceCount {
+				t.Fatalf("Path did not lock the piece.  %+v", p)
+			}
+			if played.LastScore.Spin != p.Spin {
+				t.Errorf("Spin not expected.  %+v  got: %s  want: %s", p, played.LastScore.Spin, p.Spin)
+			}
+
+			blocks := g.PieceMap[p.Piece][p.Rotation]
+			for i := 0; i < 4; i++ {
+				for j := 0; j < 4; j++ {
+					if 0 != blocks[i][j] && 0 == played.Field[p.Row+i][p.Col+j] {
+						t.Fatalf("Piece not locked in its placement.  %+v\n%s", p, played.GetDebugState())
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSpawnPlacementsNotValid(t *testing.T) {
+
+	g := newTestSteppedGame(t, 1, time.Second, DefaultLockDelay)
+
+	if p := g.SpawnPlacements(len(g.PieceMap)); nil != p {
+		t.Errorf("Placements for an unknown piece.  %v", p)
+	}
+
+	// GOAL: fill the spawn position
+	for j := 1; j < g.GameColumns+1; j++ {
+		g.Field[1][j] = 1
+		g.Field[2][j] = 1
+	}
+	if p := g.SpawnPlacements(0); nil != p {
+		t.Errorf("Placements for a piece that cannot spawn.  %v", p)
+	}
+}
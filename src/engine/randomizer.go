@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// DOC: A Randomizer picks the sequence of pieces played in a game.
+type Randomizer interface {
+	// Next returns the next piece, a number from 0 to num_pieces-1.  The
+	// prng must be the only source of randomness so that the sequence is
+	// reproducible from the game's seed.
+	Next(prng *rand.Rand, num_pieces int) int
+
+	// Copy returns a randomizer in the same state that does not share any
+	// state with this one, so each game can be given its own.
+	Copy() Randomizer
+}
+
+// UniformRandomizer picks every piece independently with equal chance.
+type UniformRandomizer struct{}
+
+// Next returns a uniformly random piece.
+func (UniformRandomizer) Next(prng *rand.Rand, num_pieces int) int {
+	return prng.Intn(num_pieces)
+}
+
+// Copy returns the randomizer, which has no state.
+func (u UniformRandomizer) Copy() Randomizer {
+	return u
+}
+
+// BagRandomizer deals pieces from a shuffled bag holding Copies of every
+// piece.  A new bag is filled when the last one is empty.  Bag holds the
+// pieces left in the current bag, in the order they will be dealt.
+type BagRandomizer struct {
+	Copies int
+	Bag    []int
+}
+
+// NewBagRandomizer creates a bag randomizer.  One copy is the usual 7-bag and
+// two copies is the 14-bag.
+func NewBagRandomizer(copies int) *BagRandomizer {
+	return &BagRandomizer{Copies: copies}
+}
+
+// Next deals the next piece from the bag.
+func (b *BagRandomizer) Next(prng *rand.Rand, num_pieces int) int {
+
+	if 0 == len(b.Bag) {
+		// GOAL: fill and shuffle a new bag
+		for c := 0; c < b.Copies; c++ {
+			for piece := 0; piece < num_pieces; piece++ {
+				b.Bag = append(b.Bag, piece)
+			}
+		}
+		prng.Shuffle(len(b.Bag), func(i, j int) {
+			b.Bag[i], b.Bag[j] = b.Bag[j], b.Bag[i]
+		})
+	}
+
+	piece := b.Bag[0]
+	b.Bag = b.Bag[1:]
+
+	return piece
+}
+
+// Copy returns a bag randomizer with a copy of the bag.
+func (b *BagRandomizer) Copy() Randomizer {
+	return &BagRandomizer{Copies: b.Copies, Bag: append([]int{}, b.Bag...)}
+}
+
+// HistoryRandomizer rerolls a random piece up to Rolls times while it is one
+// of the recently dealt pieces in History.  The last roll is kept even when
+// it is in the history.
+type HistoryRandomizer struct {
+	Rolls   int
+	History []int
+}
+
+// NewTGMRandomizer creates a history randomizer in the style of TGM 2: a four
+// piece history, six rolls, and a history that starts as Z, S, S, Z so the
+// first pieces are unlikely to be S or Z.  The piece numbers are the order
+// of the pieces in DefaultPieceMap and SRSPieceMap.
+func NewTGMRandomizer() *HistoryRandomizer {
+	return &HistoryRandomizer{Rolls: 6, History: []int{6, 4, 4, 6}}
+}
+
+// Next returns a piece that is, if possible within the rolls, not in the history.
+func (h *HistoryRandomizer) Next(prng *rand.Rand, num_pieces int) int {
+
+	piece := prng.Intn(num_pieces)
+	for roll := 1; roll < h.Rolls && h.inHistory(piece); roll++ {
+		piece = prng.Intn(num_pieces)
+	}
+
+	if 0 < len(h.History) {
+		h.History = append(h.History[1:], piece)
+	}
+
+	return piece
+}
+
+// Copy returns a history randomizer with a copy of the history.
+func (h *HistoryRandomizer) Copy() Randomizer {
+	return &HistoryRandomizer{Rolls: h.Rolls, History: append([]int{}, h.History...)}
+}
+
+// inHistory determines whether the piece was recently dealt.
+func (h *HistoryRandomizer) inHistory(piece int) bool {
+	for _, p := range h.History {
+		if p == piece {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRandomizer creates a randomizer by name.
+// Inputs:
+// - one of "random", "bag7", "bag14" or "tgm"
+// Returns:
+// - A new randomizer
+// - An error if the name is not known
+func NewRandomizer(name string) (Randomizer, error) {
+
+	switch name {
+	case "random":
+		return UniformRandomizer{}, nil
+	case "bag7":
+		return NewBagRandomizer(1), nil
+	case "bag14":
+		return NewBagRandomizer(2), nil
+	case "tgm":
+		return NewTGMRandomizer(), nil
+	}
+
+	return nil, fmt.Errorf("unknown randomizer %q", name)
+}
+
+// countingSource is a rand.Source that counts the values drawn from it, so
+// the position of a PRNG can be saved and restored from its seed.
+type countingSource struct {
+	source rand.Source64
+	draws  uint64
+}
+
+// newCountingSource creates a source from the seed that has already had the
+// number of values drawn from it.
+func newCountingSource(seed int64, draws uint64) *countingSource {
+
+	s := &countingSource{source: rand.NewSource(seed).(rand.Source64)}
+	for s.draws < draws {
+		s.Int63()
+	}
+
+	return s
+}
+
+// Int63 draws a value.
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.source.Int63()
+}
+
+// Uint64 draws a value.
+func (s *countingSource) Uint64() uint64 {
+	s.draws++
+	return s.source.Uint64()
+}
+
+// Seed restarts the source from the seed.
+func (s *countingSource) Seed(seed int64) {
+	s.source.Seed(seed)
+	s.draws = 0
+}
@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestBagRandomizer(t *testing.T) {
+
+	for _, copies := range []int{1, 2} {
+		prng := rand.New(rand.NewSource(7))
+		bag := NewBagRandomizer(copies)
+		bagSize := copies * DefaultNumberPossiblePieces
+
+		for n := 0; n < 10; n++ {
+			counts := make([]int, DefaultNumberPossiblePieces)
+			for i := 0; i < bagSize; i++ {
+				counts[bag.Next(prng, DefaultNumberPossiblePieces)]++
+			}
+
+			for piece, count := range counts {
+				if count != copies {
+					t.Errorf("Piece count in bag not expected.  bag: %d  piece: %d  got: %d  want: %d", n, piece, count, copies)
+				}
+			}
+		}
+	}
+}
+
+func TestHistoryRandomizer(t *testing.T) {
+
+	prng := rand.New(rand.NewSource(7))
+	history := &HistoryRandomizer{Rolls: 1000, History: []int{0, 1, 2, 3}}
+
+	recent := []int{0, 1, 2, 3}
+	for i := 0; i < 100; i++ {
+		piece := history.Next(prng, DefaultNumberPossiblePieces)
+
+		if slices.Contains(recent, piece) {
+			t.Errorf("Piece repeated from history.  got: %d  history: %+v", piece, recent)
+		}
+		recent = append(recent[1:], piece)
+	}
+}
+
+func TestUniformRandomizer(t *testing.T) {
+
+	prng := rand.New(rand.NewSource(7))
+	expected := rand.New(rand.NewSource(7))
+
+	for i := 0; i < 100; i++ {
+		got := UniformRandomizer{}.Next(prng, DefaultNumberPossiblePieces)
+		want := expected.Intn(DefaultNumberPossiblePieces)
+		if got != want {
+			t.Errorf("Piece not expected.  got: %d  want: %d", got, want)
+		}
+	}
+}
+
+func TestRandomizerSeeded(t *testing.T) {
+
+	for _, name := range []string{"random", "bag7", "bag14", "tgm"} {
+		first, err := NewRandomizer(name)
+		if err != nil {
+			t.Fatalf("Randomizer not created.  %s: %v", name, err)
+		}
+		second, _ := NewRandomizer(name)
+
+		config := testConfig(99)
+		config.Randomizer = first
+		a := newGameState(config)
+		config.Randomizer = second
+		b := newGameState(config)
+
+		for i := 0; i < 50; i++ {
+			if a.Piece != b.Piece {
+				t.Errorf("Same seed dealt different pieces.  %s: %d != %d", name, a.Piece, b.Piece)
+			}
+			a.nextPiece()
+			b.nextPiece()
+		}
+	}
+
+	if _, err := NewRandomizer("nope"); err == nil {
+		t.Errorf("Unknown randomizer created.")
+	}
+}
+
+func TestRandomizerNotShared(t *testing.T) {
+
+	config := testConfig(99)
+	config.Randomizer = NewBagRandomizer(1)
+	played := newGameState(config)
+	for i := 0; i < 10; i++ {
+		played.nextPiece()
+	}
+
+	// GOAL: another game from the configuration deals what a new one would
+	a := newGameState(config)
+	config.Randomizer = NewBagRandomizer(1)
+	b := newGameState(config)
+
+	for i := 0; i < 50; i++ {
+		if a.Piece != b.Piece {
+			t.Fatalf("Randomizer shared between games.  %d: %d != %d", i, a.Piece, b.Piece)
+		}
+		a.nextPiece()
+		b.nextPiece()
+	}
+}
@@ -0,0 +1,292 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DOC: The version of the replay format written by Replay.Save.  LoadReplay
+// reads only this version.
+const ReplayVersion = 1
+
+// DOC: Every replay file starts with these bytes.
+var replayMagic = []byte("TETRISREPLAY")
+
+// DOC: Limits on the sizes read from a replay file so a damaged file cannot
+// ask for huge allocations.
+const (
+	maxReplayStartSize   = 1 << 24
+	maxReplayFrameInputs = 1 << 16
+)
+
+// DOC: A Replay describes a game as the state it started from and the steps it
+// was given.  Since Step is deterministic, playing the frames from the start
+// reproduces the game exactly.
+type Replay struct {
+	// Start is the game, in the save format, when recording started.
+	Start []byte
+
+	// Frames are the steps that had player input, and the step that ended
+	// the game.  Steps without input are merged into the next frame.
+	Frames []ReplayFrame
+
+	pending time.Duration
+	ended   bool
+}
+
+// DOC: A ReplayFrame is one step of a replay: the time since the previous
+// frame and the player inputs applied after it.
+type ReplayFrame struct {
+	Elapsed time.Duration
+	Inputs  []byte
+}
+
+// RecordReplay starts recording every step given to the game.  Call it before
+// the game is started with ResumeGame, or between calls to Step, and read the
+// replay once the game is over or the main game loop has ended.
+// Returns:
+// - The replay the steps are recorded into
+// - An error if the game cannot be saved
+func (g *Game) RecordReplay() (*Replay, error) {
+
+	var start bytes.Buffer
+	if err := g.Save(&start); nil != err {
+		return nil, err
+	}
+
+	g.replay = &Replay{Start: start.Bytes()}
+	return g.replay, nil
+}
+
+// record adds a step to the replay.  Steps after the game is over are not
+// recorded.
+func (r *Replay) record(inputs []byte, elapsed time.Duration, over bool) {
+
+	if r.ended {
+		return
+	}
+
+	r.pending += elapsed
+	if 0 < len(inputs) || over {
+		r.Frames = append(r.Frames, ReplayFrame{Elapsed: r.pending, Inputs: append([]byte{}, inputs...)})
+		r.pending = 0
+	}
+	r.ended = over
+}
+
+// Game creates the game the replay started from, ready to be given the
+// frames with Step.
+// Returns:
+// - A game struct for the start of the replay
+// - An error if the start of the replay cannot be loaded
+func (r *Replay) Game() (*Game, error) {
+
+	g, err := LoadGame(bytes.NewReader(r.Start))
+	if nil != err {
+		return nil, err
+	}
+	g.takeEvents()
+
+	return g, nil
+}
+
+// Run plays the whole replay at once.
+// Returns:
+// - The game at the end of the replay
+// - An error if the start of the replay cannot be loaded
+func (r *Replay) Run() (*Game, error) {
+
+	g, err := r.Game()
+	if nil != err {
+		return nil, err
+	}
+
+	for _, frame := range r.Frames {
+		g.Step(frame.Inputs, frame.Elapsed)
+	}
+
+	return g, nil
+}
+
+// Play is a real time driver that plays the replay at the speed it was
+// recorded, until the context is cancelled.  It sends the game state, like
+// MainGameLoop, after each frame and each gravity drop or lock between frames,
+// except while the game is paused.  The output channel is closed at the end
+// of the replay.
+// Uses the clock to wait between steps, or SystemClock if clock is nil.
+// Returns:
+// - An output channel that will be sent each state change
+// - An error if the start of the replay cannot be loaded
+func (r *Replay) Play(ctx context.Context, clock Clock) (<-chan *Game, error) {
+
+	g, err := r.Game()
+	if nil != err {
+		return nil, err
+	}
+
+	if nil == clock {
+		clock = SystemClock{}
+	}
+
+	output_state_channel := make(chan *Game, 5)
+
+	// send returns false if the context was cancelled while sending.
+	send := func() bool {
+		if g.State == StatePaused {
+			return true
+		}
+		select {
+		case output_state_channel <- g.CopyOfState():
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// wait returns false if the context was cancelled while waiting.
+	timer := &wakeTimer{clock: clock}
+	wait := func(d time.Duration) bool {
+		select {
+		case <-timer.wake(d, true):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(output_state_channel)
+		defer timer.stop()
+
+		if !send() {
+			return
+		}
+
+		for _, frame := range r.Frames {
+			remaining := frame.Elapsed
+
+			// GOAL: show the game change on its own between frames
+			for {
+				d, ok := g.NextDeadline()
+				if !ok || d > remaining {
+					break
+				}
+				if !wait(d) {
+					return
+				}
+				g.Step(nil, d)
+				remaining -= d
+				if !send() {
+					return
+				}
+			}
+
+			if !wait(remaining) {
+				return
+			}
+			g.Step(frame.Inputs, remaining)
+			if !send() {
+				return
+			}
+		}
+	}()
+
+	return output_state_channel, nil
+}
+
+// Save writes the replay to w in the compact replay format: the magic bytes,
+// the version, the length of the start and the start, the number of frames,
+// and then each frame as the elapsed nanoseconds, the number of inputs and
+// the inputs.  Every number is an unsigned varint.
+// Returns:
+// - An error if writing fails
+func (r *Replay) Save(w io.Writer) error {
+
+	buffer := append([]byte{}, replayMagic...)
+	buffer = binary.AppendUvarint(buffer, ReplayVersion)
+	buffer = binary.AppendUvarint(buffer, uint64(len(r.Start)))
+	buffer = append(buffer, r.Start...)
+	buffer = binary.AppendUvarint(buffer, uint64(len(r.Frames)))
+
+	for _, frame := range r.Frames {
+		buffer = binary.AppendUvarint(buffer, uint64(frame.Elapsed))
+		buffer = binary.AppendUvarint(buffer, uint64(len(frame.Inputs)))
+		buffer = append(buffer, frame.Inputs...)
+	}
+
+	_, err := w.Write(buffer)
+	return err
+}
+
+// LoadReplay reads a replay written by Replay.Save.
+// Returns:
+// - The replay
+// - An error if the replay cannot be read
+func LoadReplay(r io.Reader) (*Replay, error) {
+
+	reader := bufio.NewReader(r)
+
+	magic := make([]byte, len(replayMagic))
+	if _, err := io.ReadFull(reader, magic); nil != err || !bytes.Equal(magic, replayMagic) {
+		return nil, errors.New("cannot load replay: not a replay")
+	}
+
+	version, err := binary.ReadUvarint(reader)
+	if nil != err {
+		return nil, fmt.Errorf("cannot load replay: %w", err)
+	}
+	if ReplayVersion != version {
+		return nil, fmt.Errorf("cannot load replay: replay version %d is not supported", version)
+	}
+
+	replay := &Replay{}
+
+	replay.Start, err = readReplayBytes(reader, maxReplayStartSize)
+	if nil != err {
+		return nil, err
+	}
+
+	count, err := binary.ReadUvarint(reader)
+	if nil != err {
+		return nil, fmt.Errorf("cannot load replay: %w", err)
+	}
+
+	for i := uint64(0); i < count; i++ {
+		elapsed, err := binary.ReadUvarint(reader)
+		if nil != err {
+			return nil, fmt.Errorf("cannot load replay: frame %d: %w", i, err)
+		}
+		inputs, err := readReplayBytes(reader, maxReplayFrameInputs)
+		if nil != err {
+			return nil, err
+		}
+		replay.Frames = append(replay.Frames, ReplayFrame{Elapsed: time.Duration(elapsed), Inputs: inputs})
+	}
+
+	return replay, nil
+}
+
+// readReplayBytes reads a length, up to limit, followed by that many bytes.
+func readReplayBytes(reader *bufio.Reader, limit uint64) ([]byte, error) {
+
+	length, err := binary.ReadUvarint(reader)
+	if nil != err {
+		return nil, fmt.Errorf("cannot load replay: %w", err)
+	}
+	if length > limit {
+		return nil, fmt.Errorf("cannot load replay: length %d is too long", length)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(reader, data); nil != err {
+		return nil, fmt.Errorf("cannot load replay: %w", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,181 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+// DOC: instantClock is a clock where every wait is already over.  It is its
+// own timer.
+type instantClock struct{}
+
+func (instantClock) Now() time.Time {
+	return time.Time{}
+}
+
+func (instantClock) NewTimer(d time.Duration) Timer {
+	return instantClock{}
+}
+
+func (instantClock) C() <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- time.Time{}
+	return ch
+}
+
+func (instantClock) Reset(d time.Duration) {}
+
+func (instantClock) Stop() {}
+
+// recordSome records a stepped game given a fixed mix of inputs, including a
+// long pause, until it is stopped.
+func recordSome(t *testing.T, seed int64) (*Game, *Replay) {
+
+	config := testConfig(seed)
+	config.Randomizer = NewBagRandomizer(1)
+	config.Gravity = TableGravity{40 * time.Millisecond}
+
+	g, err := NewSteppedGame(config)
+	if nil != err {
+		t.Fatalf("Game not created.  %v", err)
+	}
+	replay, err := g.RecordReplay()
+	if nil != err {
+		t.Fatalf("Replay not recorded.  %v", err)
+	}
+
+	playSome(g, 50)
+	g.Step([]byte{PlayInputPause}, 3*time.Millisecond)
+	g.Step(nil, 10*time.Second)
+	g.Step([]byte{PlayInputPause}, time.Second)
+	g.Step(nil, 25*time.Millisecond)
+	playSome(g, 50)
+	g.Step([]byte{PlayInputStop}, 0)
+
+	return g, replay
+}
+
+func TestReplayRun(t *testing.T) {
+
+	g, replay := recordSome(t, 11)
+
+	var buffer bytes.Buffer
+	if err := replay.Save(&buffer); nil != err {
+		t.Fatalf("Replay not saved.  %v", err)
+	}
+	loaded, err := LoadReplay(&buffer)
+	if nil != err {
+		t.Fatalf("Replay not loaded.  %v", err)
+	}
+
+	played, err := loaded.Run()
+	if nil != err {
+		t.Fatalf("Replay not run.  %v", err)
+	}
+
+	if saveString(t, g) != saveString(t, played) {
+		t.Errorf("Replayed game not expected.")
+	}
+	if played.State != StateGameOver {
+		t.Errorf("Replayed game not over.  %d", played.State)
+	}
+}
+
+func TestReplayStopsAtGameOver(t *testing.T) {
+
+	g, replay := recordSome(t, 12)
+	frames := len(replay.Frames)
+
+	g.Step([]byte{PlayInputMoveLeft}, time.Second)
+
+	if len(replay.Frames) != frames {
+		t.Errorf("Step recorded after game over.")
+	}
+}
+
+func TestReplayPlay(t *testing.T) {
+
+	g, replay := recordSome(t, 13)
+
+	output, err := replay.Play(context.Background(), instantClock{})
+	if nil != err {
+		t.Fatalf("Replay not played.  %v", err)
+	}
+
+	last, count := lastState(t, output)
+	if count <= len(replay.Frames) {
+		t.Errorf("Gravity drops between frames not sent.  %d states for %d frames", count, len(replay.Frames))
+	}
+	if saveString(t, g) != saveString(t, last) {
+		t.Errorf("Played game not expected.")
+	}
+}
+
+func TestReplayMainGameLoop(t *testing.T) {
+
+	config := testConfig(14)
+	config.Gravity = TableGravity{time.Millisecond}
+
+	g, err := NewSteppedGame(config)
+	if nil != err {
+		t.Fatalf("Game not created.  %v", err)
+	}
+	replay, err := g.RecordReplay()
+	if nil != err {
+		t.Fatalf("Replay not recorded.  %v", err)
+	}
+
+	clock, gameInput, gameOutput, _ := startTestClockedGame(g, false)
+
+	// GOAL: keep reading states so the loop never waits to send one
+	states := make(chan *Game, 1)
+	go func() {
+		var last *Game
+		for game := range gameOutput {
+			last = game
+		}
+		states <- last
+	}()
+
+	for _, input := range []byte{PlayInputMoveLeft, PlayInputRotate, PlayInputHardDrop, PlayInputPause, PlayInputPause, PlayInputHold} {
+		gameInput <- input
+		clock.Advance(3 * time.Millisecond)
+	}
+	gameInput <- PlayInputStop
+
+	last := <-states
+
+	played, err := replay.Run()
+	if nil != err {
+		t.Fatalf("Replay not run.  %v", err)
+	}
+	if saveString(t, last) != saveString(t, played) {
+		t.Errorf("Replayed game not expected.")
+	}
+}
+
+func TestLoadReplayNotValid(t *testing.T) {
+
+	_, replay := recordSome(t, 15)
+
+	var buffer bytes.Buffer
+	if err := replay.Save(&buffer); nil != err {
+		t.Fatalf("Replay not saved.  %v", err)
+	}
+	saved := buffer.Bytes()
+
+	tests := map[string][]byte{
+		"empty":     nil,
+		"magic":     append([]byte("TETRISREPLAX"), saved[len(replayMagic):]...),
+		"version":   append(append([]byte{}, replayMagic...), 99),
+		"truncated": saved[:len(saved)-1],
+	}
+
+	for name, data := range tests {
+		if _, err := LoadReplay(bytes.NewReader(data)); nil == err {
+			t.Errorf("Replay not rejected.  %s", name)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package engine
+
+// DOC: A kick is an offset tried when a piece is rotated.  Rows grow downward
+// like the rows of the field.
+type Kick struct {
+	Col int
+	Row int
+}
+
+// DOC: A RotationSystem decides which positions a piece may be moved to when
+// it is rotated.
+type RotationSystem interface {
+	// Kicks returns the offsets to try, in order, when rotating the piece
+	// from rotation `from` to rotation `to`.  The first offset that does not
+	// collide is used.  Returning no offsets forbids the rotation.
+	Kicks(piece int, from int, to int) []Kick
+}
+
+// ClassicRotation only tries the target rotation in place, without kicks.
+type ClassicRotation struct{}
+
+// Kicks returns a single zero offset.
+func (ClassicRotation) Kicks(piece int, from int, to int) []Kick {
+	return []Kick{{0, 0}}
+}
+
+// SRSRotation is the Super Rotation System.  Rotation states 0, 1, 2, 3 are
+// the SRS states 0, R, 2, L.  IPiece and OPiece are the piece numbers, in the
+// piece map, that use the I kick table and no kicks respectively.  All other
+// pieces use the J, L, S, T, Z kick table.
+// SRS has no 180 degree rotation so 180 degree turns use the kicks of the
+// common SRS+ extension for every piece but the O piece.
+type SRSRotation struct {
+	IPiece int
+	OPiece int
+}
+
+// DOC: SRS kick tables indexed by [from][to].  These are the usual tables
+// with the y axis flipped to match the row numbering of the field.
+var srsKicksJLSTZ = [4][4][]Kick{
+	0: {
+		1: {{0, 0}, {-1, 0}, {-1, -1}, {0, 2}, {-1, 2}},
+		3: {{0, 0}, {1, 0}, {1, -1}, {0, 2}, {1, 2}},
+	},
+	1: {
+		0: {{0, 0}, {1, 0}, {1, 1}, {0, -2}, {1, -2}},
+		2: {{0, 0}, {1, 0}, {1, 1}, {0, -2}, {1, -2}},
+	},
+	2: {
+		1: {{0, 0}, {-1, 0}, {-1, -1}, {0, 2}, {-1, 2}},
+		3: {{0, 0}, {1, 0}, {1, -1}, {0, 2}, {1, 2}},
+	},
+	3: {
+		0: {{0, 0}, {-1, 0}, {-1, 1}, {0, -2}, {-1, -2}},
+		2: {{0, 0}, {-1, 0}, {-1, 1}, {0, -2}, {-1, -2}},
+	},
+}
+
+var srsKicksI = [4][4][]Kick{
+	0: {
+		1: {{0, 0}, {-2, 0}, {1, 0}, {-2, 1}, {1, -2}},
+		3: {{0, 0}, {-1, 0}, {2, 0}, {-1, -2}, {2, 1}},
+	},
+	1: {
+		0: {{0, 0}, {2, 0}, {-1, 0}, {2, -1}, {-1, 2}},
+		2: {{0, 0}, {-1, 0}, {2, 0}, {-1, -2}, {2, 1}},
+	},
+	2: {
+		1: {{0, 0}, {1, 0}, {-2, 0}, {1, 2}, {-2, -1}},
+		3: {{0, 0}, {2, 0}, {-1, 0}, {2, -1}, {-1, 2}},
+	},
+	3: {
+		0: {{0, 0}, {1, 0}, {-2, 0}, {1, 2}, {-2, -1}},
+		2: {{0, 0}, {-2, 0}, {1, 0}, {-2, 1}, {1, -2}},
+	},
+}
+
+var srsKicks180 = [4][]Kick{
+	0: {{0, 0}, {0, -1}, {1, -1}, {-1, -1}, {1, 0}, {-1, 0}},
+	1: {{0, 0}, {1, 0}, {1, -2}, {1, -1}, {0, -2}, {0, -1}},
+	2: {{0, 0}, {0, 1}, {-1, 1}, {1, 1}, {-1, 0}, {1, 0}},
+	3: {{0, 0}, {-1, 0}, {-1, -2}, {-1, -1}, {0, -2}, {0, -1}},
+}
+
+// Kicks returns the SRS kick offsets for the rotation.
+func (s SRSRotation) Kicks(piece int, from int, to int) []Kick {
+	switch {
+	case piece == s.OPiece:
+		return []Kick{{0, 0}}
+	case (to-from+4)%4 == 2:
+		return srsKicks180[from%4]
+	case piece == s.IPiece:
+		return srsKicksI[from%4][to%4]
+	default:
+		return srsKicksJLSTZ[from%4][to%4]
+	}
+}
+
+// DOC: Rotation system used by NewGame.  The kicks and the I and O piece
+// numbers are those of SRSPieceMap.
+var DefaultRotationSystem RotationSystem = SRSRotation{IPiece: 0, OPiece: 3}
+
+// DOC: Mapping from piece and rotation to blocks covered using the SRS
+// orientations.  The pieces are in the same order as DefaultPieceMap but
+// rotate about the SRS centres, so only SRSPieceMap fits the SRS kicks.
+var SRSPieceMap = [][][][]int{
+	{
+		// I piece
+		{
+			{0, 0, 0, 0},
+			{1, 1, 1, 1},
+			{0, 0, 0, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 0, 1, 0},
+			{0, 0, 1, 0},
+			{0, 0, 1, 0},
+			{0, 0, 1, 0},
+		},
+		{
+			{0, 0, 0, 0},
+			{0, 0, 0, 0},
+			{1, 1, 1, 1},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 1, 0, 0},
+			{0, 1, 0, 0},
+			{0, 1, 0, 0},
+			{0, 1, 0, 0},
+		},
+	},
+	{
+		// J piece
+		{
+			{1, 0, 0, 0},
+			{1, 1, 1, 0},
+			{0, 0, 0, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 1, 1, 0},
+			{0, 1, 0, 0},
+			{0, 1, 0, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 0, 0, 0},
+			{1, 1, 1, 0},
+			{0, 0, 1, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 1, 0, 0},
+			{0, 1, 0, 0},
+			{1, 1, 0, 0},
+			{0, 0, 0, 0},
+		},
+	},
+	{
+		// L piece
+		{
+			{0, 0, 1, 0},
+			{1, 1, 1, 0},
+			{0, 0, 0, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 1, 0, 0},
+			{0, 1, 0, 0},
+			{0, 1, 1, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 0, 0, 0},
+			{1, 1, 1, 0},
+			{1, 0, 0, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{1, 1, 0, 0},
+			{0, 1, 0, 0},
+			{0, 1, 0, 0},
+			{0, 0, 0, 0},
+		},
+	},
+	{
+		// O piece
+		{
+			{0, 1, 1, 0},
+			{0, 1, 1, 0},
+			{0, 0, 0, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 1, 1, 0},
+			{0, 1, 1, 0},
+			{0, 0, 0, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 1, 1, 0},
+			{0, 1, 1, 0},
+			{0, 0, 0, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 1, 1, 0},
+			{0, 1, 1, 0},
+			{0, 0, 0, 0},
+			{0, 0, 0, 0},
+		},
+	},
+	{
+		// S piece
+		{
+			{0, 1, 1, 0},
+			{1, 1, 0, 0},
+			{0, 0, 0, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 1, 0, 0},
+			{0, 1, 1, 0},
+			{0, 0, 1, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 0, 0, 0},
+			{0, 1, 1, 0},
+			{1, 1, 0, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{1, 0, 0, 0},
+			{1, 1, 0, 0},
+			{0, 1, 0, 0},
+			{0, 0, 0, 0},
+		},
+	},
+	{
+		// T piece
+		{
+			{0, 1, 0, 0},
+			{1, 1, 1, 0},
+			{0, 0, 0, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 1, 0, 0},
+			{0, 1, 1, 0},
+			{0, 1, 0, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 0, 0, 0},
+			{1, 1, 1, 0},
+			{0, 1, 0, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 1, 0, 0},
+			{1, 1, 0, 0},
+			{0, 1, 0, 0},
+			{0, 0, 0, 0},
+		},
+	},
+	{
+		// Z piece
+		{
+			{1, 1, 0, 0},
+			{0, 1, 1, 0},
+			{0, 0, 0, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 0, 1, 0},
+			{0, 1, 1, 0},
+			{0, 1, 0, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 0, 0, 0},
+			{1, 1, 0, 0},
+			{0, 1, 1, 0},
+			{0, 0, 0, 0},
+		},
+		{
+			{0, 1, 0, 0},
+			{1, 1, 0, 0},
+			{1, 0, 0, 0},
+			{0, 0, 0, 0},
+		},
+	},
+}
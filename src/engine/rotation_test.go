@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"testing"
+)
+
+func TestSRSKickTables(t *testing.T) {
+
+	srs := SRSRotation{IPiece: 0, OPiece: 3}
+
+	for piece := 0; piece < 7; piece++ {
+		for from := 0; from < 4; from++ {
+			for _, to := range []int{(from + 1) % 4, (from + 3) % 4} {
+				kicks := srs.Kicks(piece, from, to)
+
+				expectedLength := 5
+				if piece == 3 {
+					expectedLength = 1
+				}
+				if len(kicks) != expectedLength {
+					t.Errorf("Kick count not expected.  piece: %d  %d->%d  got: %d  want: %d", piece, from, to, len(kicks), expectedLength)
+				}
+				if kicks[0] != (Kick{0, 0}) {
+					t.Errorf("First kick not in place.  piece: %d  %d->%d  got: %+v", piece, from, to, kicks[0])
+				}
+			}
+		}
+	}
+}
+
+func TestWallKick(t *testing.T) {
+
+	// GOAL: stand an SRS I piece upright against the left wall and rotate it flat.
+	config := testConfig(1)
+	config.PieceMap = SRSPieceMap
+	g := newGameState(config)
+	g.Piece = 0
+	g.PieceRotation = 1
+	g.PiecePosRow = 5
+	g.PiecePosCol = -1
+
+	if !g.rotate(1) {
+		t.Fatalf("Piece did not rotate.\n%s", g.GetDebugState())
+	}
+
+	if g.PieceRotation != 2 {
+		t.Errorf("Rotation not expected.  got: %d  want: %d", g.PieceRotation, 2)
+	}
+
+	if g.PiecePosCol != 1 || g.PiecePosRow != 5 {
+		t.Errorf("Position not expected.  got: %d,%d  want: %d,%d", g.PiecePosRow, g.PiecePosCol, 5, 1)
+	}
+
+	if g.LastKickIndex != 2 || g.LastKickOffset != (Kick{2, 0}) {
+		t.Errorf("Kick not expected.  got: %d %+v  want: %d %+v", g.LastKickIndex, g.LastKickOffset, 2, Kick{2, 0})
+	}
+
+	// GOAL: the same rotation without kicks is blocked by the wall.
+	g.RotationSystem = ClassicRotation{}
+	g.PieceRotation = 1
+	g.PiecePosCol = -1
+
+	if g.rotate(1) {
+		t.Errorf("Piece rotated into the wall.\n%s", g.GetDebugState())
+	}
+}
+
+func TestRotate180Kick(t *testing.T) {
+
+	// GOAL: an SRS T piece pointing down on the floor, with a block above its
+	// middle, can only turn over by dropping into its own space.
+	config := testConfig(1)
+	config.PieceMap = SRSPieceMap
+	g := newGameState(config)
+	g.Piece = 5
+	g.PieceRotation = 2
+	g.PiecePosRow = DefaultGameRows - 2
+	g.PiecePosCol = 4
+	g.Field[DefaultGameRows-2][5] = 1
+
+	if !g.rotate(2) {
+		t.Fatalf("Piece did not rotate.\n%s", g.GetDebugState())
+	}
+
+	if g.PieceRotation != 0 {
+		t.Errorf("Rotation not expected.  got: %d  want: %d", g.PieceRotation, 0)
+	}
+
+	if g.PiecePosRow != DefaultGameRows-1 || g.LastKickIndex != 1 {
+		t.Errorf("Kick not expected.  got: row %d kick %d  want: row %d kick %d", g.PiecePosRow, g.LastKickIndex, DefaultGameRows-1, 1)
+	}
+}
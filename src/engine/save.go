@@ -0,0 +1,370 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+)
+
+// DOC: The version of the save format written by Save.  LoadGame reads only
+// this version.
+const SaveVersion = 1
+
+// DOC: savedGame is the JSON form of a game.  The PRNG is saved as the number
+// of values drawn from it since it was seeded, and the rotation system,
+// randomizer and gravity curve are saved by name with any state they have.
+type savedGame struct {
+	Version              int
+	Seed                 int64
+	PRNGDraws            uint64
+	State                gamestate
+	GameRows             int
+	GameColumns          int
+	NumberPossiblePieces int
+	PieceMap             [][][][]int
+	RotationSystem       savedRotation
+	Randomizer           savedRandomizer
+	Gravity              savedGravity
+	LinesPerLevel        int
+	LockDelay            time.Duration
+	LockResetLimit       int
+	SpawnColumn          int
+	Field                [][]int
+	Piece                int
+	PieceRotation        int
+	PiecePosCol          int
+	PiecePosRow          int
+	LastKickIndex        int
+	LastKickOffset       Kick
+	LastRotationTurns    int
+	LastMoveRotation     bool
+	HoldPiece            int
+	HoldUsed             bool
+	NextPieces           []int
+	LockResets           int
+	Level                int
+	Score                int
+	ScorePieceCount      int
+	ScoreLineCount       int
+	ScoreSoftDropRows    int
+	ScoreHardDropRows    int
+	Combo                int
+	BackToBack           bool
+	LastScore            ScoreBreakdown
+	LowestRow            int
+	PieceSoftDropRows    int
+	PieceHardDropRows    int
+	GravityElapsed       time.Duration
+	GravityDisabled      bool
+	LockElapsed          time.Duration
+	LockActive           bool
+}
+
+type savedRotation struct {
+	Name   string
+	IPiece int `json:",omitempty"`
+	OPiece int `json:",omitempty"`
+}
+
+type savedRandomizer struct {
+	Name    string
+	Copies  int   `json:",omitempty"`
+	Bag     []int `json:",omitempty"`
+	Rolls   int   `json:",omitempty"`
+	History []int `json:",omitempty"`
+}
+
+type savedGravity struct {
+	Name   string
+	Level1 time.Duration   `json:",omitempty"`
+	Table  []time.Duration `json:",omitempty"`
+}
+
+// Save writes the game to w in the versioned JSON save format.  The game must
+// not be changing while it is saved, so save either a copy from CopyOfState,
+// like the states sent by the main game loop, or a game driven by Step.
+// Returns:
+// - An error if the game uses a rotation system, randomizer or gravity curve
+// that is not one of the engine's own, or if writing fails
+func (g *Game) Save(w io.Writer) error {
+
+	s := savedGame{
+		Version:              SaveVersion,
+		Seed:                 g.Seed,
+		State:                g.State,
+		GameRows:             g.GameRows,
+		GameColumns:          g.GameColumns,
+		NumberPossiblePieces: g.NumberPossiblePieces,
+		PieceMap:             g.PieceMap,
+		LinesPerLevel:        g.LinesPerLevel,
+		LockDelay:            g.LockDelay,
+		LockResetLimit:       g.LockResetLimit,
+		SpawnColumn:          g.SpawnColumn,
+		Field:                g.Field,
+		Piece:                g.Piece,
+		PieceRotation:        g.PieceRotation,
+		PiecePosCol:          g.PiecePosCol,
+		PiecePosRow:          g.PiecePosRow,
+		LastKickIndex:        g.LastKickIndex,
+		LastKickOffset:       g.LastKickOffset,
+		LastRotationTurns:    g.LastRotationTurns,
+		LastMoveRotation:     g.LastMoveRotation,
+		HoldPiece:            g.HoldPiece,
+		HoldUsed:             g.HoldUsed,
+		NextPieces:           g.NextPieces,
+		LockResets:           g.LockResets,
+		Level:                g.Level,
+		Score:                g.Score,
+		ScorePieceCount:      g.ScorePieceCount,
+		ScoreLineCount:       g.ScoreLineCount,
+		ScoreSoftDropRows:    g.ScoreSoftDropRows,
+		ScoreHardDropRows:    g.ScoreHardDropRows,
+		Combo:                g.Combo,
+		BackToBack:           g.BackToBack,
+		LastScore:            g.LastScore,
+		LowestRow:            g.lowestRow,
+		PieceSoftDropRows:    g.pieceSoftDropRows,
+		PieceHardDropRows:    g.pieceHardDropRows,
+		GravityElapsed:       g.gravityElapsed,
+		GravityDisabled:      g.gravityDisabled,
+		LockElapsed:          g.lockElapsed,
+		LockActive:           g.lockActive,
+	}
+
+	if nil == g.prngSource {
+		return fmt.Errorf("cannot save game: PRNG position not known")
+	}
+	s.PRNGDraws = g.prngSource.draws
+
+	switch r := g.RotationSystem.(type) {
+	case ClassicRotation:
+		s.RotationSystem = savedRotation{Name: "classic"}
+	case SRSRotation:
+		s.RotationSystem = savedRotation{Name: "srs", IPiece: r.IPiece, OPiece: r.OPiece}
+	default:
+		return fmt.Errorf("cannot save game: rotation system %T", g.RotationSystem)
+	}
+
+	switch r := g.Randomizer.(type) {
+	case UniformRandomizer:
+		s.Randomizer = savedRandomizer{Name: "random"}
+	case *BagRandomizer:
+		s.Randomizer = savedRandomizer{Name: "bag", Copies: r.Copies, Bag: r.Bag}
+	case *HistoryRandomizer:
+		s.Randomizer = savedRandomizer{Name: "history", Rolls: r.Rolls, History: r.History}
+	default:
+		return fmt.Errorf("cannot save game: randomizer %T", g.Randomizer)
+	}
+
+	switch c := g.Gravity.(type) {
+	case GuidelineGravity:
+		s.Gravity = savedGravity{Name: "guideline", Level1: c.Level1}
+	case NESGravity:
+		s.Gravity = savedGravity{Name: "nes"}
+	case TableGravity:
+		s.Gravity = savedGravity{Name: "table", Table: c}
+	default:
+		return fmt.Errorf("cannot save game: gravity curve %T", g.Gravity)
+	}
+
+	return json.NewEncoder(w).Encode(s)
+}
+
+// LoadGame reads a game written by Save.  The game is in the state it was
+// saved in and can be advanced with Step or resumed with ResumeGame.
+// Returns:
+// - A game struct for the loaded game
+// - An error if the save cannot be read or does not describe a playable game
+func LoadGame(r io.Reader) (*Game, error) {
+
+	var s savedGame
+	if err := json.NewDecoder(r).Decode(&s); nil != err {
+		return nil, fmt.Errorf("cannot load game: %w", err)
+	}
+	if SaveVersion != s.Version {
+		return nil, fmt.Errorf("cannot load game: save version %d is not supported", s.Version)
+	}
+
+	g := &Game{
+		Seed:                 s.Seed,
+		State:                s.State,
+		GameRows:             s.GameRows,
+		GameColumns:          s.GameColumns,
+		NumberPossiblePieces: s.NumberPossiblePieces,
+		PieceMap:             s.PieceMap,
+		LinesPerLevel:        s.LinesPerLevel,
+		LockDelay:            s.LockDelay,
+		LockResetLimit:       s.LockResetLimit,
+		SpawnColumn:          s.SpawnColumn,
+		Field:                s.Field,
+		Piece:                s.Piece,
+		PieceRotation:        s.PieceRotation,
+		PiecePosCol:          s.PiecePosCol,
+		PiecePosRow:          s.PiecePosRow,
+		LastKickIndex:        s.LastKickIndex,
+		LastKickOffset:       s.LastKickOffset,
+		LastRotationTurns:    s.LastRotationTurns,
+		LastMoveRotation:     s.LastMoveRotation,
+		HoldPiece:            s.HoldPiece,
+		HoldUsed:             s.HoldUsed,
+		NextPieces:           s.NextPieces,
+		LockResets:           s.LockResets,
+		Level:                s.Level,
+		Score:                s.Score,
+		ScorePieceCount:      s.ScorePieceCount,
+		ScoreLineCount:       s.ScoreLineCount,
+		ScoreSoftDropRows:    s.ScoreSoftDropRows,
+		ScoreHardDropRows:    s.ScoreHardDropRows,
+		Combo:                s.Combo,
+		BackToBack:           s.BackToBack,
+		LastScore:            s.LastScore,
+		lowestRow:            s.LowestRow,
+		pieceSoftDropRows:    s.PieceSoftDropRows,
+		pieceHardDropRows:    s.PieceHardDropRows,
+		gravityElapsed:       s.GravityElapsed,
+		gravityDisabled:      s.GravityDisabled,
+		lockElapsed:          s.LockElapsed,
+		lockActive:           s.LockActive,
+	}
+
+	switch s.RotationSystem.Name {
+	case "classic":
+		g.RotationSystem = ClassicRotation{}
+	case "srs":
+		g.RotationSystem = SRSRotation{IPiece: s.RotationSystem.IPiece, OPiece: s.RotationSystem.OPiece}
+	default:
+		return nil, fmt.Errorf("cannot load game: unknown rotation system %q", s.RotationSystem.Name)
+	}
+
+	switch s.Randomizer.Name {
+	case "random":
+		g.Randomizer = UniformRandomizer{}
+	case "bag":
+		g.Randomizer = &BagRandomizer{Copies: s.Randomizer.Copies, Bag: s.Randomizer.Bag}
+	case "history":
+		g.Randomizer = &HistoryRandomizer{Rolls: s.Randomizer.Rolls, History: s.Randomizer.History}
+	default:
+		return nil, fmt.Errorf("cannot load game: unknown randomizer %q", s.Randomizer.Name)
+	}
+
+	switch s.Gravity.Name {
+	case "guideline":
+		g.Gravity = GuidelineGravity{Level1: s.Gravity.Level1}
+	case "nes":
+		g.Gravity = NESGravity{}
+	case "table":
+		g.Gravity = TableGravity(s.Gravity.Table)
+	default:
+		return nil, fmt.Errorf("cannot load game: unknown gravity curve %q", s.Gravity.Name)
+	}
+
+	if err := g.validateLoaded(); nil != err {
+		return nil, err
+	}
+
+	// GOAL: put the PRNG back where it was when the game was saved
+	g.prngSource = newCountingSource(g.Seed, s.PRNGDraws)
+	g.PRNG = rand.New(g.prngSource)
+
+	return g, nil
+}
+
+// ResumeGame starts the main game loop of a game that is not already running
+// on one, like a loaded game or a game from NewSteppedGame, until the context
+// is cancelled.  A paused game is resumed.  Like NewGameWithContext, player
+// moves are not read once the output channel is closed.
+// Returns:
+// - The input channel that player moves will be read from until the game ends
+// - An output channel that will be sent each state change
+// - An error if the game is already over
+func ResumeGame(ctx context.Context, g *Game) (chan<- byte, <-chan *Game, error) {
+
+	if StateGameOver == g.State {
+		return nil, nil, fmt.Errorf("cannot resume game: game is over")
+	}
+
+	_, player_input_channel, output_state_channel, _ := startGame(ctx, g, false)
+	return player_input_channel, output_state_channel, nil
+}
+
+// validateLoaded checks that a loaded game can be played without the piece
+// or the field being out of range.
+func (g *Game) validateLoaded() error {
+
+	config := Config{
+		Rows:                 g.GameRows,
+		Columns:              g.GameColumns,
+		NumberPossiblePieces: g.NumberPossiblePieces,
+		PieceMap:             g.PieceMap,
+		RotationSystem:       g.RotationSystem,
+		QueueLength:          len(g.NextPieces),
+		Randomizer:           g.Randomizer,
+		Gravity:              g.Gravity,
+		LinesPerLevel:        g.LinesPerLevel,
+		LockDelay:            g.LockDelay,
+		LockResets:           g.LockResetLimit,
+		SpawnColumn:          g.SpawnColumn,
+	}
+	if err := config.Validate(); nil != err {
+		return fmt.Errorf("cannot load game: %w", err)
+	}
+	if 0 == g.SpawnColumn {
+		return fmt.Errorf("cannot load game: no spawn column")
+	}
+
+	switch g.State {
+	case StateRunning, StatePaused, StateGameOver:
+	default:
+		return fmt.Errorf("cannot load game: unknown state %d", g.State)
+	}
+
+	if len(g.Field) != g.GameRows+2 {
+		return fmt.Errorf("cannot load game: field has %d rows, want %d", len(g.Field), g.GameRows+2)
+	}
+	for i, row := range g.Field {
+		if len(row) != g.GameColumns+2 {
+			return fmt.Errorf("cannot load game: field row %d has %d columns, want %d", i, len(row), g.GameColumns+2)
+		}
+	}
+
+	validPiece := func(piece int) bool {
+		return 0 <= piece && piece < g.NumberPossiblePieces
+	}
+	if !validPiece(g.Piece) || g.PieceRotation < 0 || 4 <= g.PieceRotation {
+		return fmt.Errorf("cannot load game: piece %d rotation %d not valid", g.Piece, g.PieceRotation)
+	}
+	if -1 != g.HoldPiece && !validPiece(g.HoldPiece) {
+		return fmt.Errorf("cannot load game: held piece %d not valid", g.HoldPiece)
+	}
+	for _, piece := range g.NextPieces {
+		if !validPiece(piece) {
+			return fmt.Errorf("cannot load game: next piece %d not valid", piece)
+		}
+	}
+	if bag, ok := g.Randomizer.(*BagRandomizer); ok {
+		for _, piece := range bag.Bag {
+			if !validPiece(piece) {
+				return fmt.Errorf("cannot load game: piece %d in bag not valid", piece)
+			}
+		}
+	}
+
+	// GOAL: every block of the piece in play is inside the walls
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if 0 == g.PieceMap[g.Piece][g.PieceRotation][i][j] {
+				continue
+			}
+			row := g.PiecePosRow + i
+			col := g.PiecePosCol + j
+			if row < 1 || row > g.GameRows || col < 1 || col > g.GameColumns {
+				return fmt.Errorf("cannot load game: piece in play outside the field at row %d column %d", row, col)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+// saveString returns the saved form of the game.
+func saveString(t *testing.T, g *Game) string {
+	var buffer bytes.Buffer
+	if err := g.Save(&buffer); nil != err {
+		t.Fatalf("Game not saved.  %v", err)
+	}
+	return buffer.String()
+}
+
+// loadString loads a game from its saved form.
+func loadString(t *testing.T, saved string) *Game {
+	g, err := LoadGame(strings.NewReader(saved))
+	if nil != err {
+		t.Fatalf("Game not loaded.  %v", err)
+	}
+	return g
+}
+
+// playSome gives the game a fixed mix of moves, drops and holds.
+func playSome(g *Game, moves int) {
+	inputs := []byte{PlayInputMoveLeft, PlayInputRotate, PlayInputHold, PlayInputMoveRight, PlayInputDrop, PlayInputHardDrop, PlayInputRotateCCW}
+	for i := 0; i < moves; i++ {
+		g.Step([]byte{inputs[i%len(inputs)]}, 70*time.Millisecond)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+
+	for _, name := range []string{"random", "bag7", "tgm"} {
+		config := testConfig(3)
+		config.Randomizer, _ = NewRandomizer(name)
+		config.Gravity = TableGravity{100 * time.Millisecond, 50 * time.Millisecond}
+		config.LinesPerLevel = 1
+
+		g, err := NewSteppedGame(config)
+		if nil != err {
+			t.Fatalf("Game not created.  %v", err)
+		}
+		playSome(g, 40)
+
+		loaded := loadString(t, saveString(t, g))
+
+		// GOAL: the loaded game plays on exactly like the saved one
+		playSome(g, 200)
+		playSome(loaded, 200)
+
+		if saveString(t, g) != saveString(t, loaded) {
+			t.Errorf("Loaded game played differently.  %s", name)
+		}
+	}
+}
+
+func TestSaveCopyOfState(t *testing.T) {
+
+	config := testConfig(8)
+	config.Randomizer = NewBagRandomizer(1)
+
+	g, err := NewSteppedGame(config)
+	if nil != err {
+		t.Fatalf("Game not created.  %v", err)
+	}
+	playSome(g, 30)
+
+	loaded := loadString(t, saveString(t, g.CopyOfState()))
+
+	playSome(g, 100)
+	playSome(loaded, 100)
+
+	if saveString(t, g) != saveString(t, loaded) {
+		t.Errorf("Game loaded from a copy played differently.")
+	}
+}
+
+func TestLoadNotValid(t *testing.T) {
+
+	g, err := NewSteppedGame(testConfig(1))
+	if nil != err {
+		t.Fatalf("Game not created.  %v", err)
+	}
+	saved := saveString(t, g)
+
+	tests := []struct {
+		name  string
+		saved string
+	}{
+		{"not json", "tetris"},
+		{"version", strings.Replace(saved, `"Version":1`, `"Version":99`, 1)},
+		{"rows", strings.Replace(saved, `"GameRows":18`, `"GameRows":20`, 1)},
+		{"piece", strings.Replace(saved, `"Piece":`, `"Piece":70,"Ignored":`, 1)},
+		{"randomizer", strings.Replace(saved, `"Name":"random"`, `"Name":"psychic"`, 1)},
+	}
+
+	for _, test := range tests {
+		if _, err := LoadGame(strings.NewReader(test.saved)); nil == err {
+			t.Errorf("Save not rejected.  %s", test.name)
+		}
+	}
+}
+
+// DOC: a randomizer the engine does not know how to save
+type constantRandomizer struct{}
+
+func (constantRandomizer) Next(prng *rand.Rand, num_pieces int) int {
+	return 0
+}
+
+func (c constantRandomizer) Copy() Randomizer {
+	return c
+}
+
+func TestSaveUnknownRandomizer(t *testing.T) {
+
+	config := testConfig(1)
+	config.Randomizer = constantRandomizer{}
+
+	g, err := NewSteppedGame(config)
+	if nil != err {
+		t.Fatalf("Game not created.  %v", err)
+	}
+
+	var buffer bytes.Buffer
+	if err := g.Save(&buffer); nil == err {
+		t.Errorf("Game with unknown randomizer saved.")
+	}
+}
+
+func TestResumeGame(t *testing.T) {
+
+	_, gameInput, gameOutput := NewGameWithContext(context.Background())
+
+	game := <-gameOutput
+	gameInput <- PlayInputHardDrop
+	game = <-gameOutput
+	gameInput <- PlayInputStop
+
+	loaded := loadString(t, saveString(t, game))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, resumedOutput, err := ResumeGame(ctx, loaded)
+	if nil != err {
+		t.Fatalf("Game not resumed.  %v", err)
+	}
+
+	resumed := <-resumedOutput
+	if resumed.State != StateRunning {
+		t.Errorf("Game not running.  %d", resumed.State)
+	}
+	if resumed.ScorePieceCount != game.ScorePieceCount || resumed.Piece != game.Piece {
+		t.Errorf("Resumed game not expected.  got: %d %d  want: %d %d", resumed.ScorePieceCount, resumed.Piece, game.ScorePieceCount, game.Piece)
+	}
+
+	over, _ := lastState(t, gameOutput)
+	if _, _, err := ResumeGame(ctx, loadString(t, saveString(t, over))); nil == err {
+		t.Errorf("Finished game resumed.")
+	}
+}
@@ -0,0 +1,110 @@
+package engine
+
+// DOC: ScoreBreakdown describes the points awarded for one piece when it
+// locked.  Total is the sum of the other points.
+type ScoreBreakdown struct {
+	Lines        int
+	Spin         SpinType
+	Level        int
+	LineClear    int
+	Combo        int
+	BackToBack   int
+	PerfectClear int
+	SoftDrop     int
+	HardDrop     int
+	Total        int
+}
+
+// DOC: Points from the Tetris guideline.  Line clear, combo and perfect clear
+// points are multiplied by the level.  Drop points are per row.
+var (
+	lineClearPoints    = [5]int{0, 100, 300, 500, 800}
+	tSpinPoints        = [5]int{400, 800, 1200, 1600, 1600}
+	tSpinMiniPoints    = [5]int{100, 200, 400, 400, 400}
+	perfectClearPoints = [5]int{0, 800, 1200, 1800, 2000}
+)
+
+const (
+	comboPoints                 = 50
+	backToBackPerfectTetrisBase = 3200
+	softDropPoints              = 1
+	hardDropPoints              = 2
+)
+
+// creditSoftDrop credits rows lowered by the player's soft drop.
+func (g *Game) creditSoftDrop(rows int) {
+	g.ScoreSoftDropRows += rows
+	g.pieceSoftDropRows += rows
+}
+
+// creditHardDrop credits rows lowered by the player's hard drop.
+func (g *Game) creditHardDrop(rows int) {
+	g.ScoreHardDropRows += rows
+	g.pieceHardDropRows += rows
+}
+
+// scoreLock awards the points for the piece that just locked, with the spin
+// it was placed with, and cleared the number of lines.  It updates the combo
+// and back-to-back state and records the points in LastScore.
+// Tetrises and T-spins that clear lines are difficult clears that keep
+// back-to-back going.
+func (g *Game) scoreLock(lines int, spin SpinType) {
+
+	b := ScoreBreakdown{
+		Lines:    lines,
+		Spin:     spin,
+		Level:    g.Level,
+		SoftDrop: g.pieceSoftDropRows * softDropPoints,
+		HardDrop: g.pieceHardDropRows * hardDropPoints,
+	}
+	g.pieceSoftDropRows = 0
+	g.pieceHardDropRows = 0
+
+	switch spin {
+	case SpinFull:
+		b.LineClear = tSpinPoints[min(lines, 4)] * g.Level
+	case SpinMini:
+		b.LineClear = tSpinMiniPoints[min(lines, 4)] * g.Level
+	default:
+		b.LineClear = lineClearPoints[min(lines, 4)] * g.Level
+	}
+
+	if 0 == lines {
+		// CLAIM: a lock without a line clear ends the combo but not back-to-back
+		g.Combo = -1
+	} else {
+		g.Combo++
+		b.Combo = comboPoints * g.Combo * g.Level
+
+		difficult := 4 <= lines || SpinNone != spin
+		backToBack := difficult && g.BackToBack
+		if backToBack {
+			b.BackToBack = b.LineClear / 2
+		}
+		g.BackToBack = difficult
+
+		if g.fieldEmpty() {
+			b.PerfectClear = perfectClearPoints[min(lines, 4)] * g.Level
+			if backToBack && 4 <= lines {
+				b.PerfectClear = backToBackPerfectTetrisBase * g.Level
+			}
+		}
+	}
+
+	b.Total = b.LineClear + b.Combo + b.BackToBack + b.PerfectClear + b.SoftDrop + b.HardDrop
+
+	g.Score += b.Total
+	g.LastScore = b
+}
+
+// fieldEmpty determines whether there are no blocks left inside the walls.
+func (g *Game) fieldEmpty() bool {
+	for i := 1; i < g.GameRows+1; i++ {
+		for j := 1; j < g.GameColumns+1; j++ {
+			if 0 != g.Field[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"testing"
+)
+
+// setUpTetris fills the bottom four rows except the first column and stands
+// an I piece in that column, ready to lock.
+func setUpTetris(g *Game) {
+	for i := g.GameRows - 3; i < g.GameRows+1; i++ {
+		for j := 2; j < g.GameColumns+1; j++ {
+			g.Field[i][j] = 1
+		}
+	}
+	g.Piece = 0
+	g.PieceRotation = 1
+	g.PiecePosCol = -1
+	g.PiecePosRow = g.GameRows - 3
+}
+
+func TestScoreTetrisBackToBack(t *testing.T) {
+
+	g := newGameState(testConfig(1))
+
+	setUpTetris(g)
+	g.lockPiece()
+
+	expected := ScoreBreakdown{Lines: 4, Level: 1, LineClear: 800, PerfectClear: 2000, Total: 2800}
+	if g.LastScore != expected {
+		t.Errorf("Score not expected.\n got: %+v\nwant: %+v", g.LastScore, expected)
+	}
+
+	setUpTetris(g)
+	g.lockPiece()
+
+	expected = ScoreBreakdown{Lines: 4, Level: 1, LineClear: 800, Combo: 50, BackToBack: 400, PerfectClear: 3200, Total: 4450}
+	if g.LastScore != expected {
+		t.Errorf("Score not expected.\n got: %+v\nwant: %+v", g.LastScore, expected)
+	}
+
+	if g.Score != 2800+4450 {
+		t.Errorf("Total score not expected.  got: %d  want: %d", g.Score, 2800+4450)
+	}
+
+	if !g.BackToBack || g.Combo != 1 {
+		t.Errorf("Combo state not expected.  back to back: %t  combo: %d", g.BackToBack, g.Combo)
+	}
+}
+
+func TestScoreSingleAndDrops(t *testing.T) {
+
+	g := newGameState(testConfig(1))
+	g.Level = 3
+	g.BackToBack = true
+
+	// GOAL: a single with a block left over is not a perfect clear
+	for j := 2; j < g.GameColumns+1; j++ {
+		g.Field[g.GameRows][j] = 1
+	}
+	g.Field[g.GameRows-1][g.GameColumns] = 1
+	g.Piece = 0
+	g.PieceRotation = 1
+	g.PiecePosCol = -1
+	g.PiecePosRow = g.GameRows - 3
+	g.creditSoftDrop(3)
+	g.creditHardDrop(5)
+	g.lockPiece()
+
+	expected := ScoreBreakdown{Lines: 1, Level: 3, LineClear: 300, SoftDrop: 3, HardDrop: 10, Total: 313}
+	if g.LastScore != expected {
+		t.Errorf("Score not expected.\n got: %+v\nwant: %+v", g.LastScore, expected)
+	}
+
+	if g.BackToBack {
+		t.Errorf("Single did not break back to back.")
+	}
+
+	// GOAL: a lock without a line clear ends the combo
+	g.Piece = 3
+	g.PieceRotation = 0
+	g.PiecePosCol = 3
+	g.PiecePosRow = 1
+	g.hardDrop()
+
+	if g.Combo != -1 || g.LastScore.Lines != 0 || g.LastScore.Total != g.LastScore.HardDrop {
+		t.Errorf("Score not expected.  combo: %d  %+v", g.Combo, g.LastScore)
+	}
+}
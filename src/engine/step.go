@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"time"
+)
+
+// Step advances the game by the elapsed time and then applies each of the
+// player inputs in order.  Nothing but Step changes a running game, apart from
+// garbage, so a game is fully described by its seed and the sequence of steps
+// it was given, and how the elapsed time is split across steps does not matter.
+// While the game is paused the elapsed time is ignored and every input but
+// PlayInputPause and PlayInputStop is discarded.
+// A game recording a replay adds each step to the replay.
+// Returns:
+// - the events caused by the step
+func (g *Game) Step(inputs []byte, elapsed time.Duration) []Event {
+
+	g.advance(elapsed)
+
+	for _, input := range inputs {
+		g.applyInput(input)
+	}
+
+	if nil != g.replay {
+		g.replay.record(inputs, elapsed, StateGameOver == g.State)
+	}
+
+	return g.takeEvents()
+}
+
+// NextDeadline returns how long until the game would change without any
+// player input, at the next gravity drop or when the lock delay runs out.
+// Returns:
+// - the time until the next change
+// - false if the game only changes with player input
+func (g *Game) NextDeadline() (time.Duration, bool) {
+
+	if g.State != StateRunning {
+		return 0, false
+	}
+
+	// CLAIM: a custom curve may return any interval, but time must move on
+	interval := clampGravity(g.Gravity.Interval(g.Level))
+	next := max(interval-g.gravityElapsed, 0)
+	if g.lockActive {
+		next = min(next, max(g.LockDelay-g.lockElapsed, 0))
+	}
+
+	return next, true
+}
+
+// advance moves game time forward, handling every gravity drop and lock
+// delay that falls due on the way in the order they fall due.
+func (g *Game) advance(elapsed time.Duration) {
+
+	for g.State == StateRunning {
+
+		next, _ := g.NextDeadline()
+		locking := g.lockActive && next == max(g.LockDelay-g.lockElapsed, 0)
+
+		if elapsed < next {
+			// CLAIM: nothing falls due in the remaining time
+			g.gravityElapsed += elapsed
+			if g.lockActive {
+				g.lockElapsed += elapsed
+			}
+			return
+		}
+
+		elapsed -= next
+		g.gravityElapsed += next
+		if g.lockActive {
+			g.lockElapsed += next
+		}
+
+		if locking {
+			g.lockPiece()
+		} else {
+			g.gravityElapsed = 0
+			g.gravityDrop()
+		}
+	}
+}
+
+// gravityDrop lowers the piece one row for gravity.  When it has landed the
+// piece locks at once without a lock delay and otherwise starts the lock delay,
+// or continues it if the piece landed on this row before.
+func (g *Game) gravityDrop() {
+
+	if g.gravityDisabled {
+		return
+	}
+
+	if g.lowerPiece() {
+		return
+	}
+
+	if 0 == g.LockDelay {
+		g.lockPiece()
+	} else if !g.lockActive {
+		// GOAL: give the landed piece the lock delay to slide or spin
+		g.lockActive = true
+	}
+}
+
+// updateLock stops the lock delay when the piece is no longer landed and
+// restarts it when a landed piece was moved, up to LockResetLimit times.
+// A stopped lock delay carries on from where it stopped when gravity finds
+// the piece landed again, unless it landed lower, so a piece cannot be kept in
+// play forever by kicking it up off the stack.
+func (g *Game) updateLock(moved bool) {
+
+	if !g.lockActive {
+		return
+	}
+
+	if !g.landed() {
+		// CLAIM: the piece moved off the stack and will fall again
+		g.lockActive = false
+	} else if moved && g.LockResets < g.LockResetLimit {
+		g.LockResets++
+		g.lockElapsed = 0
+	}
+}
+
+// applyInput applies one player input command to the game.
+func (g *Game) applyInput(input byte) {
+
+	switch input {
+	case PlayInputStop:
+		if g.State != StateGameOver {
+			g.State = StateGameOver
+			g.lockActive = false
+			g.emitGameEvent(EventGameOver)
+		}
+		return
+	case PlayInputPause:
+		switch g.State {
+		case StateRunning:
+			g.State = StatePaused
+			g.emitGameEvent(EventPaused)
+		case StatePaused:
+			// GOAL: restart the gravity and lock delay timers
+			g.State = StateRunning
+			g.gravityElapsed = 0
+			g.lockElapsed = 0
+			g.emitGameEvent(EventResumed)
+		}
+		return
+	}
+
+	if g.State != StateRunning {
+		return
+	}
+
+	switch input {
+	case PlayInputMoveLeft:
+		g.updateLock(g.moveLeft())
+	case PlayInputMoveRight:
+		g.updateLock(g.moveRight())
+	case PlayInputRotate:
+		g.updateLock(g.rotate(1))
+	case PlayInputRotateCCW:
+		g.updateLock(g.rotate(3))
+	case PlayInputRotate180:
+		g.updateLock(g.rotate(2))
+	case PlayInputDrop:
+		if g.lowerPiece() {
+			g.creditSoftDrop(1)
+		} else {
+			g.lockPiece()
+		}
+	case PlayInputHardDrop:
+		g.hardDrop()
+	case PlayInputHold:
+		g.hold()
+	case PlayInputToggleDrop:
+		g.gravityDisabled = !g.gravityDisabled
+	}
+}
@@ -0,0 +1,256 @@
+package engine
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newTestSteppedGame creates a stepped game with a fixed gravity interval.
+func newTestSteppedGame(t *testing.T, seed int64, interval time.Duration, lock_delay time.Duration) *Game {
+	config := testConfig(seed)
+	config.LockDelay = lock_delay
+	config.Gravity = TableGravity{interval}
+	config.LinesPerLevel = 0
+
+	g, err := NewSteppedGame(config)
+	if nil != err {
+		t.Fatalf("Game not created.  %v", err)
+	}
+	return g
+}
+
+func TestStepGravity(t *testing.T) {
+
+	g := newTestSteppedGame(t, 1, 100*time.Millisecond, 0)
+
+	g.Step(nil, 99*time.Millisecond)
+	if g.PiecePosRow != 1 {
+		t.Errorf("Piece dropped early.  row: %d", g.PiecePosRow)
+	}
+
+	events := g.Step(nil, time.Millisecond)
+	if g.PiecePosRow != 2 {
+		t.Errorf("Piece did not drop.  row: %d", g.PiecePosRow)
+	}
+	if 1 != len(events) || events[0].Type != EventPieceMoved {
+		t.Errorf("Events not expected.  %+v", events)
+	}
+
+	g.Step(nil, 250*time.Millisecond)
+	if g.PiecePosRow != 4 {
+		t.Errorf("Piece did not drop twice.  row: %d", g.PiecePosRow)
+	}
+
+	deadline, ok := g.NextDeadline()
+	if !ok || deadline != 50*time.Millisecond {
+		t.Errorf("Deadline not expected.  got: %s  want: %s", deadline, 50*time.Millisecond)
+	}
+}
+
+func TestStepChunking(t *testing.T) {
+
+	whole := newTestSteppedGame(t, 5, 50*time.Millisecond, 200*time.Millisecond)
+	chunked := newTestSteppedGame(t, 5, 50*time.Millisecond, 200*time.Millisecond)
+
+	inputs := []byte{PlayInputMoveLeft, PlayInputRotate, PlayInputMoveRight, PlayInputRotateCCW, PlayInputHold}
+
+	for n, input := range inputs {
+		whole.Step([]byte{input}, 3*time.Second)
+		for i := 0; i < 300; i++ {
+			chunked.Step(nil, 10*time.Millisecond)
+		}
+		chunked.Step([]byte{input}, 0)
+
+		if whole.ScorePieceCount != chunked.ScorePieceCount || whole.Piece != chunked.Piece || whole.PiecePosRow != chunked.PiecePosRow || whole.PiecePosCol != chunked.PiecePosCol {
+			t.Fatalf("Games differ after step %d.\n%s\n%s", n, whole.GetDebugState(), chunked.GetDebugState())
+		}
+
+		for i := range whole.Field {
+			if !slices.Equal(whole.Field[i], chunked.Field[i]) {
+				t.Fatalf("Fields differ after step %d.\n%s\n%s", n, whole.GetDebugState(), chunked.GetDebugState())
+			}
+		}
+	}
+
+	if whole.ScorePieceCount < 10 {
+		t.Errorf("Too few pieces played.  %d", whole.ScorePieceCount)
+	}
+}
+
+func TestStepLockDelay(t *testing.T) {
+
+	g := newTestSteppedGame(t, 1, 100*time.Millisecond, 500*time.Millisecond)
+	g.LockResetLimit = 2
+
+	// GOAL: rest an I piece on the floor
+	g.Piece = 0
+	g.PieceRotation = 0
+	g.PiecePosCol = 3
+	g.PiecePosRow = 17
+
+	// GOAL: the gravity drop that fails starts the lock delay
+	g.Step(nil, 100*time.Millisecond)
+	g.Step(nil, 400*time.Millisecond)
+	if g.ScorePieceCount != 1 {
+		t.Fatalf("Piece locked early.")
+	}
+
+	// GOAL: two moves restart the lock delay and the third does not
+	for n, input := range []byte{PlayInputMoveLeft, PlayInputMoveRight, PlayInputMoveLeft} {
+		if 0 < n {
+			g.Step(nil, 400*time.Millisecond)
+		}
+		g.Step([]byte{input}, 0)
+		if g.ScorePieceCount != 1 {
+			t.Fatalf("Piece locked early.  resets: %d", g.LockResets)
+		}
+	}
+
+	g.Step(nil, 100*time.Millisecond)
+	if g.ScorePieceCount != 2 {
+		t.Errorf("Piece not locked.  resets: %d", g.LockResets)
+	}
+
+	if g.Field[18][2] != 1 || g.Field[18][5] != 1 {
+		t.Errorf("Piece not locked in place.\n%s", g.GetDebugState())
+	}
+}
+
+// zeroGravity is a custom curve with no time between gravity drops.
+type zeroGravity struct{}
+
+func (zeroGravity) Interval(level int) time.Duration {
+	return 0
+}
+
+func TestStepZeroGravity(t *testing.T) {
+
+	config := testConfig(1)
+	config.Gravity = zeroGravity{}
+	config.LockDelay = 500 * time.Millisecond
+
+	g, err := NewSteppedGame(config)
+	if nil != err {
+		t.Fatalf("Game not created.  %v", err)
+	}
+
+	// GOAL: the curve is clamped to MinimumGravityInterval
+	g.Step(nil, 10*MinimumGravityInterval)
+	if g.PiecePosRow != 11 {
+		t.Errorf("Piece did not drop.  got: %d  want: %d", g.PiecePosRow, 11)
+	}
+
+	// GOAL: a landed piece waits out the lock delay instead of dropping forever
+	g.Step(nil, time.Second)
+	if g.ScorePieceCount < 2 {
+		t.Errorf("Piece not locked.  %d", g.ScorePieceCount)
+	}
+}
+
+func TestStepLockDelayLifted(t *testing.T) {
+
+	g := newTestSteppedGame(t, 1, 100*time.Millisecond, 500*time.Millisecond)
+	g.LockResetLimit = 0
+
+	// GOAL: land an I piece on a ledge
+	for j := 3; j < 7; j++ {
+		g.Field[18][j] = 1
+	}
+	g.Piece = 0
+	g.PieceRotation = 0
+	g.PiecePosCol = 3
+	g.PiecePosRow = 15
+	g.lowestRow = 15
+
+	g.Step(nil, 200*time.Millisecond)
+	g.Step(nil, 300*time.Millisecond)
+	if g.ScorePieceCount != 1 || g.PiecePosRow != 16 {
+		t.Fatalf("Piece not landed.\n%s", g.GetDebugState())
+	}
+
+	// GOAL: lift the piece off the ledge, as a kick would, and let it land again
+	g.PiecePosRow = 15
+	g.Step([]byte{PlayInputMoveRight}, 0)
+	g.Step(nil, 200*time.Millisecond)
+	if g.ScorePieceCount != 1 || g.PiecePosRow != 16 {
+		t.Fatalf("Piece not landed again.\n%s", g.GetDebugState())
+	}
+
+	// CLAIM: the lock delay carries on instead of starting again
+	g.Step(nil, 150*time.Millisecond)
+	if g.ScorePieceCount != 1 {
+		t.Fatalf("Piece locked early.")
+	}
+	g.Step(nil, 100*time.Millisecond)
+	if g.ScorePieceCount != 2 {
+		t.Errorf("Piece not locked after the rest of the lock delay.")
+	}
+}
+
+func TestStepPause(t *testing.T) {
+
+	g := newTestSteppedGame(t, 1, 100*time.Millisecond, 0)
+
+	// GOAL: the first step also returns the spawn of the first piece
+	events := g.Step([]byte{PlayInputPause}, 0)
+	if g.State != StatePaused || 2 != len(events) || events[1].Type != EventPaused {
+		t.Fatalf("Game not paused.  %d  %+v", g.State, events)
+	}
+
+	if _, ok := g.NextDeadline(); ok {
+		t.Errorf("Paused game has a deadline.")
+	}
+
+	g.Step([]byte{PlayInputMoveLeft, PlayInputHardDrop}, 10*time.Second)
+	if g.PiecePosRow != 1 || g.PiecePosCol != g.GameColumns/2 || g.ScorePieceCount != 1 {
+		t.Errorf("Paused game changed.\n%s", g.GetDebugState())
+	}
+
+	g.Step([]byte{PlayInputPause}, 0)
+	g.Step(nil, 100*time.Millisecond)
+	if g.State != StateRunning || g.PiecePosRow != 2 {
+		t.Errorf("Game not resumed.  state: %d  row: %d", g.State, g.PiecePosRow)
+	}
+}
+
+func TestStepWholeGames(t *testing.T) {
+
+	for seed := int64(0); seed < 100; seed++ {
+		g := newTestSteppedGame(t, seed, time.Second, DefaultLockDelay)
+
+		for g.State == StateRunning && g.ScorePieceCount < 1000 {
+			g.Step([]byte{PlayInputHardDrop}, 0)
+		}
+
+		if g.State != StateGameOver {
+			t.Errorf("Game did not end.  seed: %d  pieces: %d", seed, g.ScorePieceCount)
+		}
+	}
+}
+
+func TestMainGameLoopManualClock(t *testing.T) {
+
+	clock := NewManualClock(time.Unix(0, 0))
+	g := newTestSteppedGame(t, 1, time.Second, 0)
+
+	gameInput := make(chan byte, 5)
+	gameOutput := make(chan *Game, 5)
+	g.MainGameLoop(context.Background(), clock, gameInput, gameOutput, nil)
+
+	game := <-gameOutput
+	if game.PiecePosRow != 1 {
+		t.Fatalf("Piece not at the top.  %d", game.PiecePosRow)
+	}
+
+	for row := 2; row < 5; row++ {
+		clock.WaitForWaiter()
+		clock.Advance(time.Second)
+
+		game = <-gameOutput
+		if game.PiecePosRow != row {
+			t.Errorf("Piece not dropped by the clock.  got: %d  want: %d", game.PiecePosRow, row)
+		}
+	}
+}
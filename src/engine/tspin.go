@@ -0,0 +1,141 @@
+package engine
+
+// DOC: Kinds of spin a piece can be placed with
+type SpinType int
+
+const (
+	SpinNone SpinType = iota
+	SpinMini
+	SpinFull
+)
+
+// String returns the name of the spin type.
+func (s SpinType) String() string {
+	switch s {
+	case SpinMini:
+		return "T-spin mini"
+	case SpinFull:
+		return "T-spin"
+	}
+	return "none"
+}
+
+// DOC: The index of the SRS kick that makes any T-spin a full T-spin when
+// it is used by a quarter turn.
+const tSpinFullKickIndex = 4
+
+// isFullKick reports whether a rotation of the turns that used the kick makes
+// any T-spin a full T-spin.  The 180 degree kicks are a different list, so
+// their fifth kick does not count.
+func isFullKick(turns int, kick_index int) bool {
+	return 2 != turns && tSpinFullKickIndex == kick_index
+}
+
+// tShape finds the centre block and the pointing direction of a T shaped
+// piece in the specified rotation.  A T shape has one block with three
+// neighbours and it points away from the side without a neighbour.
+// Returns:
+// - the row and column of the centre block within the 4 by 4 piece map
+// - the row and column step toward the side the piece points to
+// - false if the piece is not T shaped
+func (g *Game) tShape(piece int, rotation int) (int, int, int, int, bool) {
+
+	blocks := g.PieceMap[piece][rotation]
+	filled := func(i int, j int) bool {
+		return 0 <= i && i < 4 && 0 <= j && j < 4 && 0 != blocks[i][j]
+	}
+
+	count := 0
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if filled(i, j) {
+				count++
+			}
+		}
+	}
+	if 4 != count {
+		return 0, 0, 0, 0, false
+	}
+
+	steps := [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if !filled(i, j) {
+				continue
+			}
+
+			neighbours := 0
+			missing := 0
+			for n, step := range steps {
+				if filled(i+step[0], j+step[1]) {
+					neighbours++
+				} else {
+					missing = n
+				}
+			}
+
+			if 3 == neighbours {
+				// CLAIM: the piece points away from the missing neighbour
+				return i, j, -steps[missing][0], -steps[missing][1], true
+			}
+		}
+	}
+
+	return 0, 0, 0, 0, false
+}
+
+// detectSpin applies the 3-corner rule to the piece in play before it locks.
+// A T shaped piece whose last successful move was a rotation, with at least
+// three of the four corners around its centre blocked, is a T-spin.  It is a
+// mini T-spin unless both corners on the side it points to are blocked or the
+// rotation was a quarter turn that used the fifth SRS kick.
+func (g *Game) detectSpin() SpinType {
+	full_kick := isFullKick(g.LastRotationTurns, g.LastKickIndex)
+	return g.spinAt(g.Piece, g.PieceRotation, g.PiecePosRow, g.PiecePosCol, g.LastMoveRotation, full_kick)
+}
+
+// spinAt applies the 3-corner rule to a piece at the position, given whether
+// its last move was a rotation and whether that rotation used the kick that
+// makes a full T-spin.
+func (g *Game) spinAt(piece int, rotation int, row int, col int, last_move_rotation bool, full_kick bool) SpinType {
+
+	if !last_move_rotation {
+		return SpinNone
+	}
+
+	centre_row, centre_col, front_row, front_col, is_t := g.tShape(piece, rotation)
+	if !is_t {
+		return SpinNone
+	}
+	centre_row += row
+	centre_col += col
+
+	blocked := func(row int, col int) bool {
+		if row < 0 || row >= len(g.Field) || col < 0 || col >= len(g.Field[row]) {
+			return true
+		}
+		return 0 != g.Field[row][col]
+	}
+
+	corners := 0
+	front := 0
+	for _, dr := range []int{-1, 1} {
+		for _, dc := range []int{-1, 1} {
+			if blocked(centre_row+dr, centre_col+dc) {
+				corners++
+				if dr == front_row || dc == front_col {
+					front++
+				}
+			}
+		}
+	}
+
+	switch {
+	case corners < 3:
+		return SpinNone
+	case 2 == front || full_kick:
+		return SpinFull
+	}
+
+	return SpinMini
+}
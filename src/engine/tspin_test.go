@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"testing"
+)
+
+func TestTSpinDouble(t *testing.T) {
+
+	g := newGameState(testConfig(1))
+
+	// GOAL: make the bottom of the field look like:
+	//  [X 0 0 0 X 0 0 0 0 0 0 X]
+	//  [X X X * * * X X X X X X]
+	//  [X X X X * X X X X X X X]
+	for j := 1; j < 11; j++ {
+		g.Field[17][j] = 1
+		g.Field[18][j] = 1
+	}
+	g.Field[16][4] = 1
+	g.Field[17][4] = 0
+	g.Field[17][5] = 0
+	g.Field[17][6] = 0
+	g.Field[18][5] = 0
+
+	g.Piece = 5
+	g.PieceRotation = 2
+	g.PiecePosRow = 16
+	g.PiecePosCol = 4
+	g.LastMoveRotation = true
+
+	g.lockPiece()
+
+	if g.LastScore.Spin != SpinFull || g.LastScore.Lines != 2 {
+		t.Errorf("T-spin double not detected.  %+v", g.LastScore)
+	}
+
+	if g.LastScore.LineClear != 1200 || !g.BackToBack {
+		t.Errorf("T-spin double not scored.  %+v", g.LastScore)
+	}
+}
+
+func TestTSpinMini(t *testing.T) {
+
+	g := newGameState(testConfig(1))
+
+	// GOAL: a T piece pointing up on the floor with one front corner blocked
+	//  [X 0 0 0 X * 0 0 0 0 0 X]
+	//  [X 0 0 0 * * * 0 0 0 0 X]
+	//  [X X X X X X X X X X X X]
+	g.Field[17][4] = 1
+	g.Piece = 5
+	g.PieceRotation = 0
+	g.PiecePosRow = 17
+	g.PiecePosCol = 4
+	g.LastMoveRotation = true
+
+	spin := g.detectSpin()
+	if spin != SpinMini {
+		t.Errorf("Spin not expected.  got: %s  want: %s", spin, SpinMini)
+	}
+
+	// GOAL: the fifth kick of a 180 degree turn does not upgrade a mini T-spin
+	g.LastKickIndex = 4
+	g.LastRotationTurns = 2
+	spin = g.detectSpin()
+	if spin != SpinMini {
+		t.Errorf("Spin not expected.  got: %s  want: %s", spin, SpinMini)
+	}
+
+	// GOAL: the fifth kick of a quarter turn upgrades a mini T-spin
+	g.LastRotationTurns = 3
+	spin = g.detectSpin()
+	if spin != SpinFull {
+		t.Errorf("Spin not expected.  got: %s  want: %s", spin, SpinFull)
+	}
+
+	// GOAL: a piece that moved after rotating did not spin
+	g.LastMoveRotation = false
+	spin = g.detectSpin()
+	if spin != SpinNone {
+		t.Errorf("Spin not expected.  got: %s  want: %s", spin, SpinNone)
+	}
+
+	// GOAL: only T shaped pieces spin
+	g.Piece = 0
+	g.LastMoveRotation = true
+	spin = g.detectSpin()
+	if spin != SpinNone {
+		t.Errorf("Spin not expected.  got: %s  want: %s", spin, SpinNone)
+	}
+}
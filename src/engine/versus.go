@@ -0,0 +1,302 @@
+package engine
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// DOC: An AttackTable gives the number of garbage lines a lock sends to the
+// opponent in a match.
+type AttackTable struct {
+	// Lines is indexed by the number of lines cleared without a spin.
+	Lines [5]int
+
+	// TSpin and TSpinMini are indexed by the number of lines cleared with
+	// each kind of spin.
+	TSpin     [4]int
+	TSpinMini [3]int
+
+	// BackToBack is added to a difficult clear that continues back-to-back.
+	BackToBack int
+
+	// Combo is indexed by the combo count of the game after the lock, 0 for
+	// the first clear in a row.  Longer combos use the last entry.
+	Combo []int
+
+	// PerfectClear is added when the clear empties the field.
+	PerfectClear int
+}
+
+// DOC: The attack table from the Tetris guideline.
+var GuidelineAttack = AttackTable{
+	Lines:        [5]int{0, 0, 1, 2, 4},
+	TSpin:        [4]int{0, 2, 4, 6},
+	TSpinMini:    [3]int{0, 0, 1},
+	BackToBack:   1,
+	Combo:        []int{0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 4, 5},
+	PerfectClear: 10,
+}
+
+// Attack returns the number of garbage lines sent for the lock described by
+// the score breakdown, with the combo count of the game after the lock.
+func (t AttackTable) Attack(b ScoreBreakdown, combo int) int {
+
+	if 0 == b.Lines {
+		return 0
+	}
+
+	var lines int
+	switch b.Spin {
+	case SpinFull:
+		lines = t.TSpin[min(b.Lines, len(t.TSpin)-1)]
+	case SpinMini:
+		lines = t.TSpinMini[min(b.Lines, len(t.TSpinMini)-1)]
+	default:
+		lines = t.Lines[min(b.Lines, len(t.Lines)-1)]
+	}
+
+	if 0 < b.BackToBack {
+		lines += t.BackToBack
+	}
+	if 0 <= combo && 0 < len(t.Combo) {
+		lines += t.Combo[min(combo, len(t.Combo)-1)]
+	}
+	if 0 < b.PerfectClear {
+		lines += t.PerfectClear
+	}
+
+	return lines
+}
+
+// DOC: Garbage is a batch of garbage rows waiting to rise into a field.  Every
+// row of a batch has its hole in the same field column.
+type Garbage struct {
+	Lines int
+	Hole  int
+}
+
+// DOC: A Match is two games played against each other.  Clearing lines sends
+// garbage to the opponent, found from the attack table.  It first cancels
+// garbage waiting for the player, oldest first, and the rest waits for the
+// opponent.  Waiting garbage rises into a field when that player locks a
+// piece without clearing lines.  The match is over when either game is over.
+type Match struct {
+	Games   [2]*Game
+	Pending [2][]Garbage
+	Attack  AttackTable
+
+	// Over is set when the match is over.  Winner is then the player whose
+	// game is not over, or -1 when both games ended in the same step.
+	Over   bool
+	Winner int
+
+	prng *rand.Rand
+}
+
+// NewMatch creates a match of two games from the configuration.  Both games
+// get the same pieces.  The seed of the configuration also chooses the hole
+// column of the garbage.  Start the match with MainMatchLoop, or give it
+// steps with Step.
+// Returns:
+// - the match
+// - an error if the configuration is not valid
+func NewMatch(config Config, attack AttackTable) (*Match, error) {
+
+	if err := config.Validate(); nil != err {
+		return nil, err
+	}
+
+	m := &Match{Attack: attack, Winner: -1, prng: rand.New(rand.NewSource(config.Seed))}
+
+	for player := range m.Games {
+		g := newGameState(config)
+		g.State = StateRunning
+
+		player, opponent := player, 1-player
+		g.onLock = func(b ScoreBreakdown) {
+			m.lock(player, opponent, b)
+		}
+		m.Games[player] = g
+	}
+
+	return m, nil
+}
+
+// lock sends garbage for a lock by the player, or when the lock cleared no
+// lines, lets the garbage waiting for the player rise into the field under
+// the piece that came into play after the lock.
+func (m *Match) lock(player int, opponent int, b ScoreBreakdown) {
+
+	g := m.Games[player]
+
+	if 0 == b.Lines {
+		for _, garbage := range m.Pending[player] {
+			if StateGameOver == g.State {
+				break
+			}
+			g.insertGarbage(garbage.Lines, garbage.Hole)
+		}
+		m.Pending[player] = nil
+		return
+	}
+
+	attack := m.Attack.Attack(b, g.Combo)
+
+	// GOAL: cancel the garbage waiting for the player, oldest first
+	for 0 < attack && 0 < len(m.Pending[player]) {
+		cancelled := min(attack, m.Pending[player][0].Lines)
+		attack -= cancelled
+		m.Pending[player][0].Lines -= cancelled
+		if 0 == m.Pending[player][0].Lines {
+			m.Pending[player] = m.Pending[player][1:]
+		}
+	}
+
+	if 0 < attack {
+		hole := RandomGarbageHole(m.prng, m.Games[opponent].GameColumns)
+		m.Pending[opponent] = append(m.Pending[opponent], Garbage{Lines: attack, Hole: hole})
+	}
+}
+
+// PendingLines returns the number of garbage lines waiting for the player,
+// to show on a meter.
+func (m *Match) PendingLines(player int) int {
+
+	lines := 0
+	for _, garbage := range m.Pending[player] {
+		lines += garbage.Lines
+	}
+
+	return lines
+}
+
+// Step advances both games by the elapsed time and applies the player inputs
+// of each, the first player first.  Garbage sent in a step reaches the
+// opponent's pending garbage at once.  A match that is over ignores steps.
+// Returns:
+// - the events of each game caused by the step
+func (m *Match) Step(inputs [2][]byte, elapsed time.Duration) [2][]Event {
+
+	var events [2][]Event
+	if m.Over {
+		return events
+	}
+
+	for player, g := range m.Games {
+		events[player] = g.Step(inputs[player], elapsed)
+	}
+
+	over := [2]bool{StateGameOver == m.Games[0].State, StateGameOver == m.Games[1].State}
+	switch {
+	case over[0] && over[1]:
+		m.Over = true
+	case over[0]:
+		m.Over, m.Winner = true, 1
+	case over[1]:
+		m.Over, m.Winner = true, 0
+	}
+
+	return events
+}
+
+// NextDeadline returns how long until either game would change without any
+// player input.
+// Returns:
+// - the time until the next change
+// - false if the games only change with player input
+func (m *Match) NextDeadline() (time.Duration, bool) {
+
+	next, found := time.Duration(0), false
+	for _, g := range m.Games {
+		if d, ok := g.NextDeadline(); ok && (!found || d < next) {
+			next, found = d, true
+		}
+	}
+
+	return next, found
+}
+
+// CopyOfState creates a copy of the match for display, with a copy of the
+// state of each game.
+func (m *Match) CopyOfState() *Match {
+
+	new_copy := &Match{Attack: m.Attack, Over: m.Over, Winner: m.Winner}
+	for player, g := range m.Games {
+		new_copy.Games[player] = g.CopyOfState()
+		new_copy.Pending[player] = append([]Garbage{}, m.Pending[player]...)
+	}
+
+	return new_copy
+}
+
+// MainMatchLoop is a real time driver of Step for a match, like MainGameLoop
+// is for a game.  Reads the input of each player from player_inputs.  A pause
+// from either player pauses or resumes both games, and a stop, or closing the
+// channel, ends that player's game.  Sends the match state to match_state_ch,
+// except while paused.  Uses the clock to measure time and to wake up when
+// either game falls due, or SystemClock if clock is nil.
+// The loop ends after sending the state of the finished match or when the
+// context is cancelled, and then closes match_state_ch.
+func (m *Match) MainMatchLoop(ctx context.Context, clock Clock, player_inputs [2]<-chan byte, match_state_ch chan<- *Match) {
+
+	if nil == clock {
+		clock = SystemClock{}
+	}
+
+	go func() {
+		defer close(match_state_ch)
+
+		timer := &wakeTimer{clock: clock}
+		defer timer.stop()
+		last := clock.Now()
+
+		for {
+			if StatePaused != m.Games[0].State {
+				select {
+				case match_state_ch <- m.CopyOfState():
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if m.Over {
+				// CLAIM: the final state was sent
+				return
+			}
+
+			// GOAL: wait for player input or for either game to fall due
+			wake := timer.wake(m.NextDeadline())
+
+			var inputs [2][]byte
+			select {
+			case key, ok := <-player_inputs[0]:
+				if !ok {
+					player_inputs[0] = nil
+					key = PlayInputStop
+				}
+				inputs[0] = []byte{key}
+			case key, ok := <-player_inputs[1]:
+				if !ok {
+					player_inputs[1] = nil
+					key = PlayInputStop
+				}
+				inputs[1] = []byte{key}
+			case <-wake:
+			case <-ctx.Done():
+				return
+			}
+
+			// GOAL: pause both games together
+			for player := range inputs {
+				if 1 == len(inputs[player]) && PlayInputPause == inputs[player][0] {
+					inputs = [2][]byte{{PlayInputPause}, {PlayInputPause}}
+				}
+			}
+
+			now := clock.Now()
+			m.Step(inputs, now.Sub(last))
+			last = now
+		}
+	}()
+}
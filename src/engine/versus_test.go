@@ -0,0 +1,213 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newTestMatch creates a match of standard games with the seed.
+func newTestMatch(t *testing.T, seed int64) *Match {
+	m, err := NewMatch(testConfig(seed), GuidelineAttack)
+	if nil != err {
+		t.Fatalf("Match not created.  %v", err)
+	}
+	return m
+}
+
+func TestAttack(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		b     ScoreBreakdown
+		combo int
+		want  int
+	}{
+		{"none", ScoreBreakdown{}, -1, 0},
+		{"single", ScoreBreakdown{Lines: 1}, 0, 0},
+		{"double", ScoreBreakdown{Lines: 2}, 0, 1},
+		{"tetris", ScoreBreakdown{Lines: 4}, 0, 4},
+		{"back-to-back tetris", ScoreBreakdown{Lines: 4, BackToBack: 400}, 0, 5},
+		{"T-spin double", ScoreBreakdown{Lines: 2, Spin: SpinFull}, 0, 4},
+		{"T-spin mini double", ScoreBreakdown{Lines: 2, Spin: SpinMini}, 0, 1},
+		{"combo single", ScoreBreakdown{Lines: 1}, 3, 1},
+		{"long combo single", ScoreBreakdown{Lines: 1}, 40, 5},
+		{"perfect clear", ScoreBreakdown{Lines: 1, PerfectClear: 800}, 0, 10},
+	}
+
+	for _, test := range tests {
+		if got := GuidelineAttack.Attack(test.b, test.combo); got != test.want {
+			t.Errorf("Attack not expected.  %s  got: %d  want: %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMatchGarbage(t *testing.T) {
+
+	m := newTestMatch(t, 1)
+	g := m.Games[1]
+
+	// GOAL: a tetris by player 0 sends 4 lines to player 1
+	m.Games[0].Combo = 0
+	m.lock(0, 1, ScoreBreakdown{Lines: 4})
+	if 4 != m.PendingLines(1) || 0 != m.PendingLines(0) {
+		t.Fatalf("Pending garbage not expected.  %v", m.Pending)
+	}
+
+	// GOAL: a double by player 1 cancels 1 of the 4 lines
+	g.Combo = 0
+	m.lock(1, 0, ScoreBreakdown{Lines: 2})
+	if 3 != m.PendingLines(1) || 0 != m.PendingLines(0) {
+		t.Fatalf("Cancelled garbage not expected.  %v", m.Pending)
+	}
+
+	// GOAL: a lock without lines lets the garbage rise
+	g.Field[g.GameRows][1] = 1
+	hole := m.Pending[1][0].Hole
+	m.lock(1, 0, ScoreBreakdown{})
+	if StateGameOver == g.State {
+		t.Errorf("Garbage topped out.")
+	}
+	if 0 != m.PendingLines(1) {
+		t.Errorf("Garbage still pending.  %v", m.Pending)
+	}
+	if 0 == g.Field[g.GameRows-3][1] {
+		t.Errorf("Block not pushed up.\n%s", g.GetDebugState())
+	}
+	for i := g.GameRows - 2; i < g.GameRows+1; i++ {
+		for j := 1; j < g.GameColumns+1; j++ {
+			if (j == hole) != (0 == g.Field[i][j]) {
+				t.Fatalf("Garbage row not expected.  hole: %d\n%s", hole, g.GetDebugState())
+			}
+		}
+	}
+}
+
+func TestMatchOver(t *testing.T) {
+
+	m := newTestMatch(t, 1)
+
+	steps := 0
+	for ; !m.Over && steps < 1000; steps++ {
+		m.Step([2][]byte{{PlayInputHardDrop}, nil}, time.Millisecond)
+	}
+
+	if !m.Over || 1 != m.Winner {
+		t.Errorf("Match result not expected.  over: %v  winner: %d", m.Over, m.Winner)
+	}
+
+	pieces := m.Games[1].ScorePieceCount
+	m.Step([2][]byte{nil, {PlayInputHardDrop}}, time.Millisecond)
+	if pieces != m.Games[1].ScorePieceCount {
+		t.Errorf("Step after the match not ignored.")
+	}
+}
+
+func TestMatchSamePieces(t *testing.T) {
+
+	config := testConfig(3)
+	config.Randomizer = NewBagRandomizer(1)
+	m, err := NewMatch(config, GuidelineAttack)
+	if nil != err {
+		t.Fatalf("Match not created.  %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if m.Games[0].Piece != m.Games[1].Piece {
+			t.Fatalf("Pieces not the same.  piece: %d  got: %d  want: %d", i, m.Games[1].Piece, m.Games[0].Piece)
+		}
+		m.Step([2][]byte{{PlayInputHardDrop}, {PlayInputMoveLeft, PlayInputHardDrop}}, 0)
+	}
+
+	// GOAL: the games do not share the bag
+	if m.Games[0].Randomizer == m.Games[1].Randomizer {
+		t.Errorf("Randomizer shared by the games.")
+	}
+}
+
+func TestMainMatchLoop(t *testing.T) {
+
+	m := newTestMatch(t, 1)
+
+	inputs := [2]chan byte{make(chan byte, 1), make(chan byte, 1)}
+	states := make(chan *Match, 5)
+	m.MainMatchLoop(context.Background(), nil, [2]<-chan byte{inputs[0], inputs[1]}, states)
+
+	inputs[1] <- PlayInputHardDrop
+	inputs[0] <- PlayInputStop
+
+	var last *Match
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case state, ok := <-states:
+			if !ok {
+				done = true
+				break
+			}
+			last = state
+		case <-timeout:
+			t.Fatalf("Match loop did not end.")
+		}
+	}
+
+	if !last.Over || 1 != last.Winner {
+		t.Errorf("Final state not expected.  over: %v  winner: %d", last.Over, last.Winner)
+	}
+}
+
+func TestMatchGarbageTopOut(t *testing.T) {
+
+	m := newTestMatch(t, 1)
+	g := m.Games[1]
+
+	// GOAL: a block near the top that 3 garbage lines push out of the field
+	g.Field[3][1] = 1
+	m.Pending[1] = []Garbage{{Lines: 3, Hole: 2}}
+
+	m.Step([2][]byte{nil, {PlayInputHardDrop}}, 0)
+	if StateGameOver != g.State || !m.Over || 0 != m.Winner {
+		t.Errorf("Garbage did not top out.  state: %v  over: %v  winner: %d", g.State, m.Over, m.Winner)
+	}
+}
+
+func TestMatchGarbageLiftsPiece(t *testing.T) {
+
+	// GOAL: an SRS I piece, which spawns a row below the top of the field
+	config := testConfig(1)
+	config.PieceMap = SRSPieceMap
+	m, err := NewMatch(config, GuidelineAttack)
+	if nil != err {
+		t.Fatalf("Match not created.  %v", err)
+	}
+	g := m.Games[1]
+	g.NextPieces[0] = 0
+
+	// GOAL: a stack that 2 rows of garbage push into the next piece
+	for j := 1; j < g.GameColumns; j++ {
+		g.Field[4][j] = 1
+	}
+	m.Pending[1] = []Garbage{{Lines: 2, Hole: 1}}
+	g.Piece = 3
+	g.PieceRotation = 0
+	g.PiecePosRow = g.GameRows - 1
+	g.PiecePosCol = 1
+
+	g.lockPiece()
+
+	if StateGameOver == g.State {
+		t.Fatalf("Garbage topped out.  row: %d", g.PiecePosRow)
+	}
+	if 0 != m.PendingLines(1) {
+		t.Errorf("Garbage still pending.  %v", m.Pending)
+	}
+	if 0 == g.Field[2][2] {
+		t.Errorf("Stack not pushed up.\n%s", g.GetDebugState())
+	}
+	if 0 != g.PiecePosRow {
+		t.Errorf("Piece not lifted.  got: %d  want: %d", g.PiecePosRow, 0)
+	}
+	if pieceCollision(g, g.Piece, g.PieceRotation, g.PiecePosRow, g.PiecePosCol) {
+		t.Errorf("Piece collides with the stack.\n%s", g.GetDebugState())
+	}
+}
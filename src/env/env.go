@@ -0,0 +1,224 @@
+// Package env is a reinforcement learning environment for the engine, in the
+// style of Gym: Reset starts a seeded game, and Step applies an action and
+// returns the observation, the reward, whether the episode is done and
+// information about the step.
+package env
+
+import (
+	"fmt"
+	"time"
+
+	"superfrink.net/tetris/ai"
+	"superfrink.net/tetris/engine"
+)
+
+// DOC: An ActionSpace is the kind of action an agent chooses each step.
+type ActionSpace int
+
+const (
+	// RawActions are the player inputs in RawInputs, one per step.  Each
+	// step plays Tick of game time after the input.
+	RawActions ActionSpace = iota
+
+	// PlacementActions are the index of a placement in the Placements of the
+	// observation.  Each step plays the whole path of the placement, which
+	// locks the piece, without game time passing.
+	PlacementActions
+)
+
+// DOC: The player inputs of each raw action.  Action 0 presses nothing.
+var RawInputs = [][]byte{
+	nil,
+	{engine.PlayInputMoveLeft},
+	{engine.PlayInputMoveRight},
+	{engine.PlayInputRotate},
+	{engine.PlayInputRotateCCW},
+	{engine.PlayInputRotate180},
+	{engine.PlayInputDrop},
+	{engine.PlayInputHardDrop},
+	{engine.PlayInputHold},
+}
+
+// DOC: A Config describes the games an environment plays and how an agent
+// sees and plays them.
+type Config struct {
+	// Game is used for every episode, with the seed given to Reset and a
+	// copy of its randomizer, since randomizers keep state.
+	Game engine.Config
+
+	Actions  ActionSpace
+	Encoding Encoding
+	Reward   Reward
+
+	// Tick is the game time after each raw action.
+	Tick time.Duration
+
+	// MaxSteps ends an episode that is not over after this many steps, zero
+	// for no limit.
+	MaxSteps int
+}
+
+// DefaultConfig returns a configuration for standard games played by
+// placements, seen as the field, the pieces and the queue, and rewarded by
+// the lines cleared.
+func DefaultConfig() Config {
+	return Config{
+		Game:     engine.DefaultConfig(),
+		Actions:  PlacementActions,
+		Encoding: EncodeField | EncodePiece | EncodeQueue,
+		Reward:   LinesReward,
+		Tick:     time.Second / 60,
+	}
+}
+
+// DOC: Info describes what happened in one step.
+type Info struct {
+	// Lines, Score and Pieces are how many lines were cleared, points were
+	// scored and pieces were played in the step.
+	Lines  int
+	Score  int
+	Pieces int
+
+	// GameOver is set when the game ended in the step and Truncated when
+	// the episode ran out of steps instead, or was left with no placement
+	// to choose.
+	GameOver  bool
+	Truncated bool
+
+	// Invalid is set when the action was not in the action space.  Nothing
+	// is played for an invalid action.
+	Invalid bool
+}
+
+// DOC: An Env plays one game at a time for an agent.  It is not safe to use
+// from more than one goroutine.
+type Env struct {
+	config     Config
+	game       *engine.Game
+	steps      int
+	placements []ai.Placement
+	stuck      bool
+}
+
+// New creates an environment.  Call Reset to start the first episode.
+// Returns:
+// - the environment
+// - an error if the configuration is not valid
+func New(config Config) (*Env, error) {
+
+	if err := config.Game.Validate(); nil != err {
+		return nil, err
+	}
+	if config.Actions != RawActions && config.Actions != PlacementActions {
+		return nil, fmt.Errorf("invalid env config: unknown action space %d", config.Actions)
+	}
+	if RawActions == config.Actions && config.Tick <= 0 {
+		return nil, fmt.Errorf("invalid env config: tick must be more than zero")
+	}
+	if 0 == config.Encoding {
+		return nil, fmt.Errorf("invalid env config: no observation encoding")
+	}
+	if nil == config.Reward {
+		return nil, fmt.Errorf("invalid env config: no reward")
+	}
+	if config.MaxSteps < 0 {
+		return nil, fmt.Errorf("invalid env config: max steps must not be negative")
+	}
+
+	return &Env{config: config}, nil
+}
+
+// Reset starts a new episode with a game of the seed.
+// Returns:
+// - the observation of the new game
+// - an error if the game cannot be created
+func (e *Env) Reset(seed int64) (Observation, error) {
+
+	game_config := e.config.Game
+	game_config.Seed = seed
+
+	g, err := engine.NewSteppedGame(game_config)
+	if nil != err {
+		return Observation{}, fmt.Errorf("env: cannot create game: %w", err)
+	}
+
+	e.game = g
+	e.steps = 0
+
+	return e.observe(), nil
+}
+
+// Step applies the action to the game.  Stepping an episode that is done, or
+// before Reset, plays nothing.
+// Returns:
+// - the observation after the step
+// - the reward for the step
+// - true when the episode is done, because the game is over, the episode
+// ran out of steps or the piece in play has no placement to choose
+// - information about the step
+func (e *Env) Step(action int) (Observation, float64, bool, Info) {
+
+	var info Info
+
+	if nil == e.game {
+		info.Invalid = true
+		return Observation{}, 0, true, info
+	}
+	if e.done() {
+		info.Invalid = true
+		return e.observe(), 0, true, info
+	}
+
+	lines, score, pieces := e.game.ScoreLineCount, e.game.Score, e.game.ScorePieceCount
+
+	switch e.config.Actions {
+	case RawActions:
+		if action < 0 || action >= len(RawInputs) {
+			info.Invalid = true
+			break
+		}
+		e.game.Step(RawInputs[action], e.config.Tick)
+	case PlacementActions:
+		if action < 0 || action >= len(e.placements) {
+			info.Invalid = true
+			break
+		}
+		for _, input := range e.placements[action].Path {
+			e.game.Step([]byte{input}, 0)
+		}
+	}
+	e.steps++
+
+	o := e.observe()
+
+	info.Lines = e.game.ScoreLineCount - lines
+	info.Score = e.game.Score - score
+	info.Pieces = e.game.ScorePieceCount - pieces
+	info.GameOver = engine.StateGameOver == e.game.State
+	info.Truncated = !info.GameOver && e.done()
+
+	return o, e.config.Reward(e.game, info), e.done(), info
+}
+
+// done determines whether the episode is over.  An episode played by
+// placements is also over when the last observation found no placement,
+// since the agent then has no action to choose.
+func (e *Env) done() bool {
+	return engine.StateGameOver == e.game.State || (0 < e.config.MaxSteps && e.steps >= e.config.MaxSteps) || e.stuck
+}
+
+// NumActions returns the number of actions the agent can choose from now.
+// For placement actions it is the number of placements in the last
+// observation.
+func (e *Env) NumActions() int {
+	if RawActions == e.config.Actions {
+		return len(RawInputs)
+	}
+	return len(e.placements)
+}
+
+// Game returns the game of the episode, nil before Reset.  Changing it
+// changes the episode.
+func (e *Env) Game() *engine.Game {
+	return e.game
+}
@@ -0,0 +1,203 @@
+package env
+
+import (
+	"slices"
+	"testing"
+
+	"superfrink.net/tetris/ai"
+	"superfrink.net/tetris/engine"
+)
+
+// newTestEnv creates an environment with the action space, or fails the test.
+func newTestEnv(t *testing.T, actions ActionSpace) *Env {
+	config := DefaultConfig()
+	config.Actions = actions
+	e, err := New(config)
+	if nil != err {
+		t.Fatalf("Env not created.  %v", err)
+	}
+	return e
+}
+
+// resetTestEnv starts an episode of the seed, or fails the test.
+func resetTestEnv(t *testing.T, e *Env, seed int64) Observation {
+	o, err := e.Reset(seed)
+	if nil != err {
+		t.Fatalf("Env not reset.  %v", err)
+	}
+	return o
+}
+
+func TestNewNotValid(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		change func(c *Config)
+	}{
+		{"game", func(c *Config) { c.Game.Rows = 0 }},
+		{"actions", func(c *Config) { c.Actions = 7 }},
+		{"tick", func(c *Config) { c.Actions = RawActions; c.Tick = 0 }},
+		{"encoding", func(c *Config) { c.Encoding = 0 }},
+		{"reward", func(c *Config) { c.Reward = nil }},
+		{"max steps", func(c *Config) { c.MaxSteps = -1 }},
+	}
+
+	for _, test := range tests {
+		config := DefaultConfig()
+		test.change(&config)
+		if _, err := New(config); nil == err {
+			t.Errorf("Config not rejected.  %s", test.name)
+		}
+	}
+}
+
+func TestResetDeterministic(t *testing.T) {
+
+	// GOAL: deal from a bag, which would carry on between episodes if shared
+	config := DefaultConfig()
+	config.Game.Randomizer = engine.NewBagRandomizer(1)
+	e, err := New(config)
+	if nil != err {
+		t.Fatalf("Env not created.  %v", err)
+	}
+
+	first := resetTestEnv(t, e, 5)
+	for i := 0; i < 10; i++ {
+		e.Step(0)
+	}
+	again := resetTestEnv(t, e, 5)
+
+	if !slices.Equal(first.Vector(), again.Vector()) {
+		t.Errorf("Reset not deterministic.  got: %v  want: %v", again.Vector(), first.Vector())
+	}
+	if 1 != e.Game().ScorePieceCount {
+		t.Errorf("Reset did not start a new game.  pieces: %d", e.Game().ScorePieceCount)
+	}
+}
+
+func TestStepPlacements(t *testing.T) {
+
+	e := newTestEnv(t, PlacementActions)
+	player := ai.NewPlayer(ai.DefaultWeights)
+
+	o := resetTestEnv(t, e, 1)
+	total := 0.0
+	for step := 0; step < 50; step++ {
+		// GOAL: let the ai player choose among the placement actions
+		best := 0
+		for i, p := range o.Placements {
+			if player.Weights.Evaluate(e.Game(), p) > player.Weights.Evaluate(e.Game(), o.Placements[best]) {
+				best = i
+			}
+		}
+
+		var reward float64
+		var done bool
+		var info Info
+		o, reward, done, info = e.Step(best)
+		if done || info.Invalid || 0 == info.Pieces {
+			t.Fatalf("Step not expected.  step: %d  info: %+v", step, info)
+		}
+		total += reward
+	}
+
+	if total < 5 || total != float64(e.Game().ScoreLineCount) {
+		t.Errorf("Lines reward not expected.  got: %v  lines: %d", total, e.Game().ScoreLineCount)
+	}
+}
+
+func TestStepRaw(t *testing.T) {
+
+	e := newTestEnv(t, RawActions)
+	if len(RawInputs) != e.NumActions() {
+		t.Errorf("Number of actions not expected.  got: %d  want: %d", e.NumActions(), len(RawInputs))
+	}
+
+	resetTestEnv(t, e, 1)
+	col := e.Game().PiecePosCol
+	e.Step(1)
+	if e.Game().PiecePosCol != col-1 {
+		t.Errorf("Move left not played.  got: %d  want: %d", e.Game().PiecePosCol, col-1)
+	}
+
+	_, _, done, info := e.Step(7)
+	if done || 1 != info.Pieces {
+		t.Errorf("Hard drop not played.  %+v", info)
+	}
+
+	// GOAL: hard drop until the game is over
+	for !done {
+		_, _, done, info = e.Step(7)
+	}
+	if !info.GameOver || info.Truncated {
+		t.Errorf("Game over not expected.  %+v", info)
+	}
+
+	if _, _, done, info = e.Step(0); !done || !info.Invalid {
+		t.Errorf("Step after the game not expected.  done: %v  %+v", done, info)
+	}
+}
+
+func TestStepNotValid(t *testing.T) {
+
+	e := newTestEnv(t, PlacementActions)
+
+	if _, _, done, info := e.Step(0); !done || !info.Invalid {
+		t.Errorf("Step before reset not expected.  done: %v  %+v", done, info)
+	}
+
+	o := resetTestEnv(t, e, 1)
+	_, _, done, info := e.Step(len(o.Placements))
+	if done || !info.Invalid || 0 != info.Pieces {
+		t.Errorf("Invalid action not expected.  done: %v  %+v", done, info)
+	}
+}
+
+func TestMaxSteps(t *testing.T) {
+
+	config := DefaultConfig()
+	config.Actions = RawActions
+	config.MaxSteps = 3
+	e, err := New(config)
+	if nil != err {
+		t.Fatalf("Env not created.  %v", err)
+	}
+
+	resetTestEnv(t, e, 1)
+	for step := 1; step <= 3; step++ {
+		_, _, done, info := e.Step(0)
+		if done != (3 == step) || info.Truncated != (3 == step) {
+			t.Errorf("Step %d not expected.  done: %v  %+v", step, done, info)
+		}
+	}
+	if engine.StateRunning != e.Game().State {
+		t.Errorf("Game not running.  state: %v", e.Game().State)
+	}
+}
+
+func TestNoPlacements(t *testing.T) {
+
+	e := newTestEnv(t, PlacementActions)
+	resetTestEnv(t, e, 1)
+
+	// GOAL: fill the cells of the piece in play so it has nowhere to go, and
+	// use up the hold
+	g := e.Game()
+	blocks := g.PieceMap[g.Piece][g.PieceRotation]
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if 0 != blocks[i][j] {
+				g.Field[g.PiecePosRow+i][g.PiecePosCol+j] = 1
+			}
+		}
+	}
+	g.HoldUsed = true
+	e.observe()
+
+	if 0 != e.NumActions() || !e.done() {
+		t.Errorf("Episode not over.  actions: %d", e.NumActions())
+	}
+	if _, _, done, info := e.Step(0); !done || !info.Invalid {
+		t.Errorf("Step without placements not expected.  done: %v  %+v", done, info)
+	}
+}
@@ -0,0 +1,166 @@
+package env
+
+import (
+	"superfrink.net/tetris/ai"
+	"superfrink.net/tetris/engine"
+)
+
+// DOC: An Encoding chooses the parts of the game an observation holds.
+// Encodings are combined with |.
+type Encoding int
+
+const (
+	// EncodeField is the field inside the walls, row by row from the top,
+	// with 1 for a block and 0 for an empty cell.
+	EncodeField Encoding = 1 << iota
+
+	// EncodeActivePiece is the cells of the piece in play laid out like
+	// EncodeField.
+	EncodeActivePiece
+
+	// EncodeHeights is the height of each column, the number of rows from
+	// the floor to the highest block.
+	EncodeHeights
+
+	// EncodePiece is a one-hot of the piece in play and a one-hot of the
+	// held piece, all 0 when no piece is held.
+	EncodePiece
+
+	// EncodeQueue is a one-hot of each upcoming piece.
+	EncodeQueue
+)
+
+// DOC: An Observation is the game as an agent sees it.  Each part is only
+// set when its encoding is chosen.
+type Observation struct {
+	Field       []float64
+	ActivePiece []float64
+	Heights     []float64
+	Piece       []float64
+	Hold        []float64
+	Queue       []float64
+
+	// Placements are the actions the agent can choose from for placement
+	// actions.
+	Placements []ai.Placement
+}
+
+// Vector joins the parts of the observation in the order of the fields of
+// Observation, as the input of a model.
+func (o Observation) Vector() []float64 {
+
+	var vector []float64
+	for _, part := range [][]float64{o.Field, o.ActivePiece, o.Heights, o.Piece, o.Hold, o.Queue} {
+		vector = append(vector, part...)
+	}
+
+	return vector
+}
+
+// observe encodes the game of the episode and finds the placements for
+// placement actions.
+func (e *Env) observe() Observation {
+
+	g := e.game
+	encoding := e.config.Encoding
+	var o Observation
+
+	if 0 != encoding&EncodeField {
+		o.Field = make([]float64, 0, g.GameRows*g.GameColumns)
+		for i := 1; i < g.GameRows+1; i++ {
+			for j := 1; j < g.GameColumns+1; j++ {
+				o.Field = append(o.Field, float64(min(g.Field[i][j], 1)))
+			}
+		}
+	}
+
+	if 0 != encoding&EncodeActivePiece {
+		o.ActivePiece = make([]float64, g.GameRows*g.GameColumns)
+		if engine.StateGameOver != g.State {
+			blocks := g.PieceMap[g.Piece][g.PieceRotation]
+			for i := 0; i < 4; i++ {
+				for j := 0; j < 4; j++ {
+					row, col := g.PiecePosRow+i-1, g.PiecePosCol+j-1
+					if 0 != blocks[i][j] && 0 <= row && row < g.GameRows && 0 <= col && col < g.GameColumns {
+						o.ActivePiece[row*g.GameColumns+col] = 1
+					}
+				}
+			}
+		}
+	}
+
+	if 0 != encoding&EncodeHeights {
+		o.Heights = make([]float64, g.GameColumns)
+		for j := 1; j < g.GameColumns+1; j++ {
+			for i := 1; i < g.GameRows+1; i++ {
+				if 0 != g.Field[i][j] {
+					o.Heights[j-1] = float64(g.GameRows + 1 - i)
+					break
+				}
+			}
+		}
+	}
+
+	if 0 != encoding&EncodePiece {
+		o.Piece = oneHot(g.Piece, g.NumberPossiblePieces)
+		o.Hold = oneHot(g.HoldPiece, g.NumberPossiblePieces)
+	}
+
+	if 0 != encoding&EncodeQueue {
+		for _, piece := range g.NextPieces {
+			o.Queue = append(o.Queue, oneHot(piece, g.NumberPossiblePieces)...)
+		}
+	}
+
+	e.placements = nil
+	e.stuck = false
+	if PlacementActions == e.config.Actions && !e.done() {
+		e.placements = ai.Placements(g)
+		e.stuck = 0 == len(e.placements)
+	}
+	o.Placements = e.placements
+
+	return o
+}
+
+// oneHot returns n values that are 0 except for a 1 at the index, or all 0
+// when the index is out of range.
+func oneHot(index int, n int) []float64 {
+
+	values := make([]float64, n)
+	if 0 <= index && index < n {
+		values[index] = 1
+	}
+
+	return values
+}
+
+// ObservationSize returns the length of the vector of every observation.
+func (e *Env) ObservationSize() int {
+
+	c := e.config.Game
+	cells := c.Rows * c.Columns
+	pieces := c.NumberPossiblePieces
+	if 0 == pieces {
+		pieces = len(c.PieceMap)
+	}
+
+	size := 0
+	if 0 != e.config.Encoding&EncodeField {
+		size += cells
+	}
+	if 0 != e.config.Encoding&EncodeActivePiece {
+		size += cells
+	}
+	if 0 != e.config.Encoding&EncodeHeights {
+		size += c.Columns
+	}
+	if 0 != e.config.Encoding&EncodePiece {
+		size += 2 * pieces
+	}
+	if 0 != e.config.Encoding&EncodeQueue {
+		size += c.QueueLength * pieces
+	}
+
+	return size
+}
@@ -0,0 +1,62 @@
+package env
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestObservationEncodings(t *testing.T) {
+
+	config := DefaultConfig()
+	config.Actions = RawActions
+	config.Encoding = EncodeField | EncodeActivePiece | EncodeHeights | EncodePiece | EncodeQueue
+	e, err := New(config)
+	if nil != err {
+		t.Fatalf("Env not created.  %v", err)
+	}
+
+	resetTestEnv(t, e, 1)
+	g := e.Game()
+	g.Field[g.GameRows][1] = 1
+	g.Field[g.GameRows-2][3] = 1
+	o := e.observe()
+
+	cols := g.GameColumns
+	want_field := make([]float64, g.GameRows*cols)
+	want_field[(g.GameRows-1)*cols] = 1
+	want_field[(g.GameRows-3)*cols+2] = 1
+	if !slices.Equal(o.Field, want_field) {
+		t.Errorf("Field not expected.  got: %v  want: %v", o.Field, want_field)
+	}
+
+	want_heights := make([]float64, cols)
+	want_heights[0] = 1
+	want_heights[2] = 3
+	if !slices.Equal(o.Heights, want_heights) {
+		t.Errorf("Heights not expected.  got: %v  want: %v", o.Heights, want_heights)
+	}
+
+	active := 0.0
+	for _, v := range o.ActivePiece {
+		active += v
+	}
+	if 4 != active {
+		t.Errorf("Active piece not expected.  %v", o.ActivePiece)
+	}
+
+	if 1 != o.Piece[g.Piece] || 0 != slices.Max(o.Hold) {
+		t.Errorf("Pieces not expected.  piece: %v  hold: %v", o.Piece, o.Hold)
+	}
+	for n, piece := range g.NextPieces {
+		if 1 != o.Queue[n*g.NumberPossiblePieces+piece] {
+			t.Errorf("Queue not expected.  %v", o.Queue)
+		}
+	}
+
+	if len(o.Vector()) != e.ObservationSize() {
+		t.Errorf("Vector size not expected.  got: %d  want: %d", len(o.Vector()), e.ObservationSize())
+	}
+	if nil != o.Placements {
+		t.Errorf("Placements for raw actions.  %v", o.Placements)
+	}
+}
@@ -0,0 +1,39 @@
+package env
+
+import (
+	"superfrink.net/tetris/ai"
+	"superfrink.net/tetris/engine"
+)
+
+// DOC: A Reward rates a step from the game after it and what happened in it.
+// Higher is better.
+type Reward func(g *engine.Game, info Info) float64
+
+// LinesReward rewards each line cleared.
+func LinesReward(g *engine.Game, info Info) float64 {
+	return float64(info.Lines)
+}
+
+// ScoreReward rewards the points scored.
+func ScoreReward(g *engine.Game, info Info) float64 {
+	return float64(info.Score)
+}
+
+// SurvivalReward rewards each piece played and punishes the end of the game.
+func SurvivalReward(g *engine.Game, info Info) float64 {
+	if info.GameOver {
+		return -1
+	}
+	return float64(info.Pieces)
+}
+
+// HeuristicReward rewards the field by the board heuristic of the ai package
+// with the weights, each time a piece is played.
+func HeuristicReward(weights ai.Weights) Reward {
+	return func(g *engine.Game, info Info) float64 {
+		if 0 == info.Pieces {
+			return 0
+		}
+		return weights.EvaluateField(g.Field, g.GameRows, g.GameColumns)
+	}
+}
@@ -0,0 +1,38 @@
+package env
+
+import (
+	"testing"
+
+	"superfrink.net/tetris/ai"
+)
+
+func TestRewards(t *testing.T) {
+
+	e := newTestEnv(t, PlacementActions)
+	resetTestEnv(t, e, 1)
+	g := e.Game()
+
+	info := Info{Lines: 2, Score: 300, Pieces: 1}
+	if got := LinesReward(g, info); 2 != got {
+		t.Errorf("Lines reward not expected.  got: %v  want: %v", got, 2)
+	}
+	if got := ScoreReward(g, info); 300 != got {
+		t.Errorf("Score reward not expected.  got: %v  want: %v", got, 300)
+	}
+	if got := SurvivalReward(g, info); 1 != got {
+		t.Errorf("Survival reward not expected.  got: %v  want: %v", got, 1)
+	}
+	if got := SurvivalReward(g, Info{Pieces: 1, GameOver: true}); -1 != got {
+		t.Errorf("Survival reward not expected.  got: %v  want: %v", got, -1)
+	}
+
+	// GOAL: a block on the floor has height 1
+	g.Field[g.GameRows][1] = 1
+	reward := HeuristicReward(ai.Weights{AggregateHeight: -1})
+	if got := reward(g, info); -1 != got {
+		t.Errorf("Heuristic reward not expected.  got: %v  want: %v", got, -1)
+	}
+	if got := reward(g, Info{}); 0 != got {
+		t.Errorf("Heuristic reward without a piece not expected.  got: %v  want: %v", got, 0)
+	}
+}
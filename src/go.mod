@@ -4,9 +4,14 @@ go 1.21
 
 toolchain go1.21.6
 
-require github.com/nsf/termbox-go v1.1.1
+require (
+	github.com/nsf/termbox-go v1.1.1
+	golang.org/x/crypto v0.33.0
+	golang.org/x/net v0.35.0
+)
 
 require (
 	github.com/mattn/go-runewidth v0.0.15 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
+	golang.org/x/sys v0.30.0 // indirect
 )
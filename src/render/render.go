@@ -0,0 +1,82 @@
+// Package render draws games as text on a grid of character cells, the same
+// way for every front end that shows a game in a terminal.
+package render
+
+import (
+	"fmt"
+
+	"superfrink.net/tetris/engine"
+)
+
+// DOC: A Canvas is a grid of character cells a game is drawn on, like a
+// terminal screen.
+type Canvas interface {
+	// Print writes the string across the cells from the row and column.
+	Print(row int, col int, str string)
+}
+
+// Piece draws a piece in its spawn rotation inside a box 4 columns wide and 3
+// rows high with the top left corner at the specified row and column.  A
+// negative piece clears the box.
+func Piece(c Canvas, row int, col int, piece_map [][][][]int, piece int) {
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 4; j++ {
+			if 0 <= piece && 0 != piece_map[piece][0][i][j] {
+				c.Print(row+i, col+j, "*")
+			} else {
+				c.Print(row+i, col+j, " ")
+			}
+		}
+	}
+}
+
+// InfoColumn returns the column of the score beside a game drawn with its left
+// side at the specified column.
+func InfoColumn(col int, game_state *engine.Game) int {
+	return col + game_state.GameColumns + 5
+}
+
+// Game draws the field, the piece in play, the held and upcoming pieces and
+// the score of a game with its left side at the specified column.  The piece
+// in play is not drawn once the game is over, since it did not fit.
+func Game(c Canvas, col int, game_state *engine.Game) {
+
+	// GOAL: Draw the game field
+	for i := 0; i < game_state.GameRows+2; i++ {
+		for j := 0; j < game_state.GameColumns+2; j++ {
+			if 0 != game_state.Field[i][j] {
+				c.Print(i, col+j, "X")
+			} else {
+				c.Print(i, col+j, " ")
+			}
+		}
+	}
+
+	// GOAL: Draw the piece in play
+	if engine.StateGameOver != game_state.State {
+		for i := 0; i < 4; i++ {
+			for j := 0; j < 4; j++ {
+				if 0 != game_state.PieceMap[game_state.Piece][game_state.PieceRotation][i][j] {
+					c.Print(game_state.PiecePosRow+i, col+game_state.PiecePosCol+j, "*")
+				}
+			}
+		}
+	}
+
+	// GOAL: Draw the held piece
+	info_col := InfoColumn(col, game_state)
+	c.Print(2, info_col+15, "Hold:")
+	Piece(c, 3, info_col+15, game_state.PieceMap, game_state.HoldPiece)
+
+	// GOAL: Draw the upcoming pieces
+	c.Print(2, info_col+23, "Next:")
+	for n, piece := range game_state.NextPieces {
+		Piece(c, 3+3*n, info_col+23, game_state.PieceMap, piece)
+	}
+
+	// GOAL: Draw the score
+	c.Print(2, info_col, fmt.Sprintf("Pieces: %d", game_state.ScorePieceCount))
+	c.Print(3, info_col, fmt.Sprintf("Lines:  %d", game_state.ScoreLineCount))
+	c.Print(4, info_col, fmt.Sprintf("Level:  %d", game_state.Level))
+	c.Print(5, info_col, fmt.Sprintf("Score:  %d", game_state.Score))
+}
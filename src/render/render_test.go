@@ -0,0 +1,54 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"superfrink.net/tetris/engine"
+)
+
+// DOC: textCanvas is a canvas of lines of text that grow to fit.
+type textCanvas []string
+
+// Print writes the string over the text from the row and column.
+func (t *textCanvas) Print(row int, col int, str string) {
+	for len(*t) <= row {
+		*t = append(*t, "")
+	}
+	line := []rune((*t)[row])
+	for len(line) < col+len([]rune(str)) {
+		line = append(line, ' ')
+	}
+	copy(line[col:], []rune(str))
+	(*t)[row] = string(line)
+}
+
+func TestGame(t *testing.T) {
+
+	g, err := engine.NewSteppedGame(engine.DefaultBucketConfig())
+	if nil != err {
+		t.Fatalf("Game not created.  %v", err)
+	}
+
+	var canvas textCanvas
+	Game(&canvas, 2, g)
+
+	// GOAL: the field has walls and the piece in play on the top row
+	if want := "  X * X"; !strings.HasPrefix(canvas[1], want) {
+		t.Errorf("Top row not expected.  got: %q  want: %q", canvas[1], want)
+	}
+	if want := "  XXXXX"; want != canvas[g.GameRows+1][:7] {
+		t.Errorf("Floor not expected.  got: %q  want: %q", canvas[g.GameRows+1], want)
+	}
+	if !strings.Contains(canvas[2], "Pieces: 1") || !strings.Contains(canvas[2], "Next:") {
+		t.Errorf("Score not drawn.  got: %q", canvas[2])
+	}
+
+	// GOAL: the piece that did not fit is not drawn once the game is over
+	g.State = engine.StateGameOver
+	canvas = nil
+	Game(&canvas, 2, g)
+	if want := "  X   X"; !strings.HasPrefix(canvas[1], want) {
+		t.Errorf("Top row after game over not expected.  got: %q  want: %q", canvas[1], want)
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DOC: ErrBadMessage is returned by Receive for a message that cannot be
+// read.  The connection can still be used.
+var ErrBadMessage = errors.New("bad message")
+
+// DOC: A LineConn is a Conn that reads and writes one JSON message per line.
+type LineConn struct {
+	scanner *bufio.Scanner
+	encoder *json.Encoder
+}
+
+// NewLineConn creates a connection of JSON lines over the reader and writer,
+// such as a net.Conn.
+func NewLineConn(rw io.ReadWriter) *LineConn {
+	return &LineConn{scanner: bufio.NewScanner(rw), encoder: json.NewEncoder(rw)}
+}
+
+// Receive reads the next message.  Blank lines are skipped.
+// Returns:
+// - the message
+// - ErrBadMessage if the line is not a message, or the error from reading
+func (c *LineConn) Receive() (Message, error) {
+
+	for c.scanner.Scan() {
+		line := strings.TrimSpace(c.scanner.Text())
+		if "" == line {
+			continue
+		}
+
+		var m Message
+		if err := json.Unmarshal([]byte(line), &m); nil != err {
+			return Message{}, fmt.Errorf("%w: %v", ErrBadMessage, err)
+		}
+		return m, nil
+	}
+
+	if err := c.scanner.Err(); nil != err {
+		return Message{}, err
+	}
+	return Message{}, io.EOF
+}
+
+// Send writes the message as one line.
+func (c *LineConn) Send(m Message) error {
+	return c.encoder.Encode(m)
+}
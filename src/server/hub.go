@@ -0,0 +1,276 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	"superfrink.net/tetris/engine"
+)
+
+// DOC: A Hub keeps the games being played so they can be found by id and
+// watched.  Games leave the hub when they are over.
+type Hub struct {
+	mu      sync.Mutex
+	games   map[string]*HostedGame
+	next_id int
+}
+
+// DOC: A HostedGame is a game running on its main game loop, and the
+// watchers of its state.
+type HostedGame struct {
+	id   string
+	done chan struct{}
+
+	input_mu sync.Mutex
+	input    chan<- byte
+	stopped  bool
+
+	mu       sync.Mutex
+	last     *engine.Game
+	watchers map[*Watcher]bool
+}
+
+// DOC: A Watcher receives the latest state of a game.  A watcher that falls
+// behind only misses states in between, never the latest one.
+type Watcher struct {
+	updates   chan *engine.Game
+	spectator bool
+}
+
+// NewHub creates a hub without games.
+func NewHub() *Hub {
+	return &Hub{games: map[string]*HostedGame{}}
+}
+
+// Start creates a game of the configuration and runs it on its main game loop
+// until it is over or the context is cancelled.
+// Returns:
+// - the game
+// - an error if the configuration is not valid
+func (hub *Hub) Start(ctx context.Context, config engine.Config) (*HostedGame, error) {
+
+	g, err := engine.NewSteppedGame(config)
+	if nil != err {
+		return nil, err
+	}
+	input, states, err := engine.ResumeGame(ctx, g)
+	if nil != err {
+		return nil, err
+	}
+
+	hub.mu.Lock()
+	hub.next_id++
+	h := &HostedGame{
+		id:       strconv.Itoa(hub.next_id),
+		input:    input,
+		done:     make(chan struct{}),
+		watchers: map[*Watcher]bool{},
+	}
+	hub.games[h.id] = h
+	hub.mu.Unlock()
+
+	go func() {
+		h.broadcast(states)
+
+		hub.mu.Lock()
+		delete(hub.games, h.id)
+		hub.mu.Unlock()
+	}()
+
+	return h, nil
+}
+
+// Find returns the game with the id.
+// Returns:
+// - the game
+// - an error if no game being played has the id
+func (hub *Hub) Find(id string) (*HostedGame, error) {
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	h, ok := hub.games[id]
+	if !ok {
+		return nil, fmt.Errorf("no game %q", id)
+	}
+
+	return h, nil
+}
+
+// List describes the games being played, in the order they were started.
+func (hub *Hub) List() []GameInfo {
+
+	hub.mu.Lock()
+	games := make([]*HostedGame, 0, len(hub.games))
+	for _, h := range hub.games {
+		games = append(games, h)
+	}
+	hub.mu.Unlock()
+
+	infos := []GameInfo{}
+	for _, h := range games {
+		h.mu.Lock()
+		info := GameInfo{Game: h.id}
+		for w := range h.watchers {
+			if w.spectator {
+				info.Spectators++
+			}
+		}
+		if nil != h.last {
+			info.Pieces = h.last.ScorePieceCount
+			info.Score = h.last.Score
+		}
+		h.mu.Unlock()
+		infos = append(infos, info)
+	}
+
+	slices.SortFunc(infos, func(a GameInfo, b GameInfo) int {
+		id_a, _ := strconv.Atoi(a.Game)
+		id_b, _ := strconv.Atoi(b.Game)
+		return id_a - id_b
+	})
+
+	return infos
+}
+
+// ID returns the id of the game in its hub.
+func (h *HostedGame) ID() string {
+	return h.id
+}
+
+// Input gives the player input to the game.  Inputs from several goroutines
+// reach the game one at a time.
+// Returns:
+// - an error if the game is over or stopped
+func (h *HostedGame) Input(input byte) error {
+
+	h.input_mu.Lock()
+	defer h.input_mu.Unlock()
+
+	if h.stopped || h.Over() {
+		return fmt.Errorf("game %s is over", h.id)
+	}
+
+	select {
+	case h.input <- input:
+		return nil
+	case <-h.done:
+		return fmt.Errorf("game %s is over", h.id)
+	}
+}
+
+// Stop ends the game by closing its input.  Stopping again does nothing.
+func (h *HostedGame) Stop() {
+
+	h.input_mu.Lock()
+	defer h.input_mu.Unlock()
+
+	if !h.stopped {
+		h.stopped = true
+		close(h.input)
+	}
+}
+
+// Done returns a channel that is closed when the main game loop has ended.
+func (h *HostedGame) Done() <-chan struct{} {
+	return h.done
+}
+
+// Over determines whether the main game loop of the game has ended.
+func (h *HostedGame) Over() bool {
+	select {
+	case <-h.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Last returns the latest state of the game, or nil before the first one.
+func (h *HostedGame) Last() *engine.Game {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.last
+}
+
+// Watch adds a watcher of the game that starts with its latest state.
+// Spectators are counted in the list of games.
+// Returns:
+// - the watcher
+// - an error if the game is over
+func (h *HostedGame) Watch(spectator bool) (*Watcher, error) {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if nil == h.watchers {
+		return nil, errors.New("game is over")
+	}
+
+	w := &Watcher{updates: make(chan *engine.Game, 1), spectator: spectator}
+	if nil != h.last {
+		w.offer(h.last)
+	}
+	h.watchers[w] = true
+
+	return w, nil
+}
+
+// Unwatch removes the watcher and closes its updates, unless the end of the
+// game already did.
+func (h *HostedGame) Unwatch(w *Watcher) {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.watchers[w] {
+		delete(h.watchers, w)
+		close(w.updates)
+	}
+}
+
+// broadcast gives every state of the game to its watchers until the main
+// game loop ends, and then closes the watchers.
+func (h *HostedGame) broadcast(states <-chan *engine.Game) {
+
+	for state := range states {
+		h.mu.Lock()
+		h.last = state
+		for w := range h.watchers {
+			w.offer(state)
+		}
+		h.mu.Unlock()
+	}
+
+	h.mu.Lock()
+	close(h.done)
+	for w := range h.watchers {
+		close(w.updates)
+	}
+	h.watchers = nil
+	h.mu.Unlock()
+}
+
+// Updates returns the channel of states, which is closed when the watcher
+// is removed or the game is over.  The state of a finished game is always
+// the last one received.
+func (w *Watcher) Updates() <-chan *engine.Game {
+	return w.updates
+}
+
+// offer replaces any state the watcher has not taken yet with the state.
+// States are only offered while holding the lock of the game, so there is
+// never more than one offer at a time.
+func (w *Watcher) offer(state *engine.Game) {
+	select {
+	case <-w.updates:
+	default:
+	}
+	w.updates <- state
+}
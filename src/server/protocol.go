@@ -0,0 +1,178 @@
+// Package server hosts tetris games for remote players and spectators.  Each
+// connection speaks a protocol of JSON messages, one per line.
+//
+// A client sends:
+//   - {"type":"create"} to host a new game and play it.  The optional "seed"
+//     picks the pieces.
+//   - {"type":"spectate","game":"1"} to watch a game without playing it.
+//   - {"type":"list"} to get the games being played.
+//   - {"type":"input","input":"left"} to play the game it created.  The
+//     inputs are the names in InputNames.
+//
+// Create and spectate take an optional "updates" of "snapshot", the default,
+// or "delta".  The server replies to create and spectate with "created" or
+// "spectating" and the game id, and then sends a "state" message with a
+// snapshot of the game after every change.  With deltas, only the first
+// message is a "state" and the rest are "delta" messages that leave out the
+// field and instead have the rows of the field that changed.  Lists are
+// answered with "games" and mistakes with "error".
+package server
+
+import (
+	"fmt"
+	"slices"
+
+	"superfrink.net/tetris/engine"
+)
+
+// DOC: The name of each player input in the protocol.
+var InputNames = map[string]byte{
+	"stop":       engine.PlayInputStop,
+	"pause":      engine.PlayInputPause,
+	"left":       engine.PlayInputMoveLeft,
+	"right":      engine.PlayInputMoveRight,
+	"rotate":     engine.PlayInputRotate,
+	"rotate_ccw": engine.PlayInputRotateCCW,
+	"rotate_180": engine.PlayInputRotate180,
+	"drop":       engine.PlayInputDrop,
+	"hard_drop":  engine.PlayInputHardDrop,
+	"hold":       engine.PlayInputHold,
+}
+
+// DOC: Ways a connection can receive the state of a game.
+const (
+	UpdatesSnapshot = "snapshot"
+	UpdatesDelta    = "delta"
+)
+
+// DOC: A Message is one line of the protocol in either direction.  Only the
+// fields used by its type are set.
+type Message struct {
+	Type    string     `json:"type"`
+	Game    string     `json:"game,omitempty"`
+	Seed    *int64     `json:"seed,omitempty"`
+	Updates string     `json:"updates,omitempty"`
+	Input   string     `json:"input,omitempty"`
+	Error   string     `json:"error,omitempty"`
+	Games   []GameInfo `json:"games,omitempty"`
+	State   *Snapshot  `json:"state,omitempty"`
+	Rows    []RowDelta `json:"rows,omitempty"`
+}
+
+// DOC: GameInfo describes a game being played, for a list.
+type GameInfo struct {
+	Game       string `json:"game"`
+	Spectators int    `json:"spectators"`
+	Pieces     int    `json:"pieces"`
+	Score      int    `json:"score"`
+}
+
+// DOC: A Snapshot is the state of a game as clients see it.  Field rows and
+// columns are numbered from 0 inside the walls, and Cells are the cells of
+// the piece in play in the same numbering.
+type Snapshot struct {
+	State    string   `json:"state"`
+	Field    [][]int  `json:"field,omitempty"`
+	Piece    int      `json:"piece"`
+	Rotation int      `json:"rotation"`
+	Cells    [][2]int `json:"cells"`
+	Hold     int      `json:"hold"`
+	Next     []int    `json:"next"`
+	Score    int      `json:"score"`
+	Lines    int      `json:"lines"`
+	Level    int      `json:"level"`
+	Pieces   int      `json:"pieces"`
+}
+
+// DOC: A RowDelta is a row of the field that changed and its new cells.
+type RowDelta struct {
+	Row   int   `json:"row"`
+	Cells []int `json:"cells"`
+}
+
+// stateName returns the protocol name of the state of a game.
+func stateName(g *engine.Game) string {
+	switch g.State {
+	case engine.StateRunning:
+		return "running"
+	case engine.StatePaused:
+		return "paused"
+	case engine.StateGameOver:
+		return "over"
+	}
+	return "starting"
+}
+
+// NewSnapshot describes the game, such as a copy from CopyOfState.
+func NewSnapshot(g *engine.Game) *Snapshot {
+
+	s := &Snapshot{
+		State:    stateName(g),
+		Field:    make([][]int, g.GameRows),
+		Piece:    g.Piece,
+		Rotation: g.PieceRotation,
+		Cells:    [][2]int{},
+		Hold:     g.HoldPiece,
+		Next:     append([]int{}, g.NextPieces...),
+		Score:    g.Score,
+		Lines:    g.ScoreLineCount,
+		Level:    g.Level,
+		Pieces:   g.ScorePieceCount,
+	}
+
+	for i := range s.Field {
+		s.Field[i] = append([]int{}, g.Field[i+1][1:g.GameColumns+1]...)
+	}
+
+	if engine.StateGameOver != g.State {
+		blocks := g.PieceMap[g.Piece][g.PieceRotation]
+		for i := 0; i < 4; i++ {
+			for j := 0; j < 4; j++ {
+				if 0 != blocks[i][j] {
+					s.Cells = append(s.Cells, [2]int{g.PiecePosRow + i - 1, g.PiecePosCol + j - 1})
+				}
+			}
+		}
+	}
+
+	return s
+}
+
+// Delta finds the rows of the field that changed since the previous snapshot.
+// Returns:
+// - the snapshot without its field
+// - the rows that changed
+func (s *Snapshot) Delta(previous *Snapshot) (*Snapshot, []RowDelta) {
+
+	var rows []RowDelta
+	for i, row := range s.Field {
+		if i >= len(previous.Field) || !slices.Equal(row, previous.Field[i]) {
+			rows = append(rows, RowDelta{Row: i, Cells: row})
+		}
+	}
+
+	without_field := *s
+	without_field.Field = nil
+
+	return &without_field, rows
+}
+
+// Apply updates the snapshot with a delta message, the snapshot without its
+// field and the rows that changed.
+// Returns:
+// - an error if a row is outside the field
+func (s *Snapshot) Apply(delta *Snapshot, rows []RowDelta) error {
+
+	field := s.Field
+	*s = *delta
+	s.Field = field
+
+	for _, row := range rows {
+		if row.Row < 0 || row.Row >= len(s.Field) {
+			return fmt.Errorf("delta row %d is outside the field", row.Row)
+		}
+		s.Field[row.Row] = append([]int{}, row.Cells...)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"slices"
+	"testing"
+
+	"superfrink.net/tetris/engine"
+)
+
+func TestSnapshotDelta(t *testing.T) {
+
+	g, err := engine.NewSteppedGame(engine.DefaultConfig())
+	if nil != err {
+		t.Fatalf("Game not created.  %v", err)
+	}
+
+	before := NewSnapshot(g.CopyOfState())
+	g.Step([]byte{engine.PlayInputHardDrop}, 0)
+	after := NewSnapshot(g.CopyOfState())
+
+	delta, rows := after.Delta(before)
+	if nil != delta.Field || 0 == len(rows) {
+		t.Fatalf("Delta not expected.  %+v  rows: %v", delta, rows)
+	}
+	for _, row := range rows {
+		if slices.Equal(before.Field[row.Row], row.Cells) {
+			t.Errorf("Row did not change.  %+v", row)
+		}
+	}
+
+	if err := before.Apply(delta, rows); nil != err {
+		t.Fatalf("Delta not applied.  %v", err)
+	}
+	if before.Pieces != after.Pieces || !slices.Equal(before.Cells, after.Cells) {
+		t.Errorf("Applied snapshot not expected.  got: %+v  want: %+v", before, after)
+	}
+	for i := range after.Field {
+		if !slices.Equal(before.Field[i], after.Field[i]) {
+			t.Errorf("Applied row not expected.  row: %d  got: %v  want: %v", i, before.Field[i], after.Field[i])
+		}
+	}
+
+	if err := before.Apply(delta, []RowDelta{{Row: len(after.Field)}}); nil == err {
+		t.Errorf("Row outside the field applied.")
+	}
+}
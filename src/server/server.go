@@ -0,0 +1,229 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"superfrink.net/tetris/engine"
+)
+
+// DOC: A Conn is a connection to one client that messages are received from
+// and sent to.  Send is never called from more than one goroutine at once.
+type Conn interface {
+	Receive() (Message, error)
+	Send(m Message) error
+}
+
+// DOC: A Server hosts games for the connections it serves.  Each game is
+// played by the connection that created it and watched by any number of
+// spectators.
+type Server struct {
+	config engine.Config
+	hub    *Hub
+}
+
+// NewServer creates a server for games of the configuration.
+// Returns:
+// - the server
+// - an error if the configuration is not valid
+func NewServer(config engine.Config) (*Server, error) {
+
+	if err := config.Validate(); nil != err {
+		return nil, err
+	}
+
+	return &Server{
+		config: config,
+		hub:    NewHub(),
+	}, nil
+}
+
+// Serve accepts connections that speak the protocol as JSON lines until the
+// context is cancelled, and then closes the listener and every connection.
+// Returns:
+// - nil once the context is cancelled and every connection is closed
+// - an error if accepting fails
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	stop := context.AfterFunc(ctx, func() { listener.Close() })
+	defer stop()
+
+	for {
+		conn, err := listener.Accept()
+		if nil != err {
+			if nil != ctx.Err() {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+
+			stop := context.AfterFunc(ctx, func() { conn.Close() })
+			defer stop()
+
+			s.ServeConn(ctx, NewLineConn(conn))
+		}()
+	}
+}
+
+// ServeConn plays the protocol with one client until it disconnects.  Games
+// created by the client run until they are over or the context is cancelled.
+// A client that disconnects stops the game it was playing.
+func (s *Server) ServeConn(ctx context.Context, c Conn) {
+
+	var send_mu sync.Mutex
+	send := func(m Message) error {
+		send_mu.Lock()
+		defer send_mu.Unlock()
+		return c.Send(m)
+	}
+	send_error := func(format string, args ...any) {
+		send(Message{Type: "error", Error: fmt.Sprintf(format, args...)})
+	}
+
+	var pumps sync.WaitGroup
+	var game *HostedGame
+	var w *Watcher
+
+	// leave stops watching the game, and playing it if this client created it.
+	leave := func() {
+		if nil == game {
+			return
+		}
+		game.Unwatch(w)
+		if !w.spectator {
+			game.Stop()
+		}
+		game, w = nil, nil
+	}
+	defer pumps.Wait()
+	defer leave()
+
+	for {
+		m, err := c.Receive()
+		if errors.Is(err, ErrBadMessage) {
+			send_error("%v", err)
+			continue
+		}
+		if nil != err {
+			return
+		}
+
+		switch m.Type {
+		case "create", "spectate":
+			if nil != game && !game.Over() {
+				send_error("already in game %s", game.ID())
+				continue
+			}
+			updates := m.Updates
+			if "" == updates {
+				updates = UpdatesSnapshot
+			}
+			if UpdatesSnapshot != updates && UpdatesDelta != updates {
+				send_error("unknown updates %q", m.Updates)
+				continue
+			}
+
+			var next *HostedGame
+			var reply string
+			if "create" == m.Type {
+				seed := time.Now().UTC().UnixNano()
+				if nil != m.Seed {
+					seed = *m.Seed
+				}
+				next, err = s.create(ctx, seed)
+				reply = "created"
+			} else {
+				next, err = s.hub.Find(m.Game)
+				reply = "spectating"
+			}
+			if nil != err {
+				send_error("%v", err)
+				continue
+			}
+
+			next_watcher, err := next.Watch("spectate" == m.Type)
+			if nil != err {
+				if "create" == m.Type {
+					next.Stop()
+				}
+				send_error("%v", err)
+				continue
+			}
+
+			leave()
+			game, w = next, next_watcher
+			send(Message{Type: reply, Game: game.ID(), Updates: updates})
+
+			pumps.Add(1)
+			go func() {
+				defer pumps.Done()
+				Pump(next_watcher, updates, send)
+			}()
+
+		case "list":
+			send(Message{Type: "games", Games: s.hub.List()})
+
+		case "input":
+			input, ok := InputNames[m.Input]
+			switch {
+			case nil == game || w.spectator:
+				send_error("not playing a game")
+			case !ok:
+				send_error("unknown input %q", m.Input)
+			default:
+				if err := game.Input(input); nil != err {
+					send_error("%v", err)
+				}
+			}
+
+		default:
+			send_error("unknown message type %q", m.Type)
+		}
+	}
+}
+
+// Pump sends each state the watcher receives as a "state" message, or as a
+// "delta" from the previous state sent, until the watcher is closed or
+// sending fails.  Updates is UpdatesSnapshot or UpdatesDelta.
+func Pump(w *Watcher, updates string, send func(Message) error) {
+
+	var previous *Snapshot
+	for state := range w.updates {
+		snapshot := NewSnapshot(state)
+
+		m := Message{Type: "state", State: snapshot}
+		if UpdatesDelta == updates && nil != previous {
+			m.Type = "delta"
+			m.State, m.Rows = snapshot.Delta(previous)
+		}
+		previous = snapshot
+
+		if err := send(m); nil != err {
+			// GOAL: keep draining so the game never waits on this watcher
+			for range w.updates {
+			}
+			return
+		}
+	}
+}
+
+// create starts a new game with the seed in the hub of the server.
+func (s *Server) create(ctx context.Context, seed int64) (*HostedGame, error) {
+
+	config := s.config
+	config.Seed = seed
+
+	return s.hub.Start(ctx, config)
+}
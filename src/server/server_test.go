@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"superfrink.net/tetris/engine"
+)
+
+// DOC: testClient is a loopback connection to a test server.
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	line *LineConn
+}
+
+// startServer serves standard games on a loopback port until the test ends.
+// Returns:
+// - the address to connect to
+// - a function that stops the server and waits for it
+func startServer(t *testing.T) (string, func()) {
+
+	s, err := NewServer(engine.DefaultConfig())
+	if nil != err {
+		t.Fatalf("Server not created.  %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatalf("Listen failed.  %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(ctx, listener)
+	}()
+
+	stop := func() {
+		cancel()
+		if err := <-served; nil != err {
+			t.Errorf("Serve failed.  %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		if nil == ctx.Err() {
+			stop()
+		}
+	})
+
+	return listener.Addr().String(), stop
+}
+
+// dial connects a client to the server.
+func dial(t *testing.T, addr string) *testClient {
+
+	conn, err := net.Dial("tcp", addr)
+	if nil != err {
+		t.Fatalf("Dial failed.  %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &testClient{t: t, conn: conn, line: NewLineConn(conn)}
+}
+
+// send sends a message to the server.
+func (c *testClient) send(m Message) {
+	if err := c.line.Send(m); nil != err {
+		c.t.Fatalf("Send failed.  %v", err)
+	}
+}
+
+// until receives messages until one satisfies done, failing after a timeout.
+func (c *testClient) until(done func(m Message) bool) Message {
+
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		m, err := c.line.Receive()
+		if nil != err {
+			c.t.Fatalf("Receive failed.  %v", err)
+		}
+		if done(m) {
+			return m
+		}
+	}
+}
+
+// receive receives messages until one of the type.
+func (c *testClient) receive(message_type string) Message {
+	return c.until(func(m Message) bool { return message_type == m.Type })
+}
+
+func TestPlayAndSpectate(t *testing.T) {
+
+	addr, _ := startServer(t)
+	player := dial(t, addr)
+	spectator := dial(t, addr)
+
+	seed := int64(1)
+	player.send(Message{Type: "create", Seed: &seed})
+	created := player.receive("created")
+	if "" == created.Game || UpdatesSnapshot != created.Updates {
+		t.Fatalf("Created not expected.  %+v", created)
+	}
+	first := player.receive("state")
+	if 1 != first.State.Pieces || "running" != first.State.State || 4 != len(first.State.Cells) {
+		t.Errorf("First state not expected.  %+v", first.State)
+	}
+
+	spectator.send(Message{Type: "spectate", Game: created.Game, Updates: UpdatesDelta})
+	spectator.receive("spectating")
+	watched := spectator.receive("state").State
+	if engine.DefaultGameRows != len(watched.Field) {
+		t.Fatalf("Spectator snapshot has no field.  %+v", watched)
+	}
+
+	// GOAL: the spectator sees the lock as a delta with the new field
+	player.send(Message{Type: "input", Input: "hard_drop"})
+	played := player.until(func(m Message) bool { return 2 == m.State.Pieces }).State
+
+	spectator.until(func(m Message) bool {
+		if "delta" != m.Type {
+			t.Fatalf("Spectator update not a delta.  %+v", m)
+		}
+		if err := watched.Apply(m.State, m.Rows); nil != err {
+			t.Fatalf("Delta not applied.  %v", err)
+		}
+		return 2 == watched.Pieces
+	})
+	for i := range played.Field {
+		if !slices.Equal(played.Field[i], watched.Field[i]) {
+			t.Fatalf("Spectator field not expected.  row: %d  got: %v  want: %v", i, watched.Field[i], played.Field[i])
+		}
+	}
+
+	// GOAL: spectators are listed and cannot play
+	spectator.send(Message{Type: "input", Input: "left"})
+	spectator.receive("error")
+
+	player.send(Message{Type: "list"})
+	games := player.receive("games").Games
+	if 1 != len(games) || created.Game != games[0].Game || 1 != games[0].Spectators {
+		t.Errorf("Games not expected.  %+v", games)
+	}
+
+	// GOAL: the game ends when the player leaves
+	player.conn.Close()
+	spectator.until(func(m Message) bool { return "over" == m.State.State })
+
+	spectator.send(Message{Type: "spectate", Game: created.Game})
+	spectator.receive("error")
+}
+
+func TestProtocolErrors(t *testing.T) {
+
+	addr, _ := startServer(t)
+	c := dial(t, addr)
+
+	messages := []string{
+		`{"type":"dance"}`,
+		`not json`,
+		`{"type":"input","input":"left"}`,
+		`{"type":"spectate","game":"99"}`,
+		`{"type":"create","updates":"sometimes"}`,
+	}
+	for _, line := range messages {
+		if _, err := c.conn.Write([]byte(line + "\n")); nil != err {
+			t.Fatalf("Write failed.  %v", err)
+		}
+		if m := c.receive("error"); "" == m.Error {
+			t.Errorf("Error not explained.  %s", line)
+		}
+	}
+
+	c.send(Message{Type: "create"})
+	c.receive("created")
+	c.send(Message{Type: "input", Input: "jump"})
+	c.receive("error")
+	c.send(Message{Type: "create"})
+	c.receive("error")
+}
+
+func TestServeStops(t *testing.T) {
+
+	addr, stop := startServer(t)
+	c := dial(t, addr)
+
+	c.send(Message{Type: "create"})
+	c.receive("created")
+
+	stop()
+
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, err := c.line.Receive(); nil != err {
+			break
+		}
+	}
+	if _, err := net.Dial("tcp", addr); nil == err {
+		t.Errorf("Server still listening.")
+	}
+}
+
+func TestGamesOwnRandomizer(t *testing.T) {
+
+	bag := engine.NewBagRandomizer(1)
+	config := engine.DefaultConfig()
+	config.Randomizer = bag
+
+	s, err := NewServer(config)
+	if nil != err {
+		t.Fatalf("Server not created.  %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for seed := int64(1); seed < 3; seed++ {
+		game, err := s.create(ctx, seed)
+		if nil != err {
+			t.Fatalf("Game not created.  %v", err)
+		}
+		defer game.Stop()
+	}
+
+	// CLAIM: a game that dealt from the bag of the configuration filled it
+	if 0 != len(bag.Bag) {
+		t.Errorf("Randomizer of the configuration shared by the games.  bag: %v", bag.Bag)
+	}
+}
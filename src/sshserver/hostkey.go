@@ -0,0 +1,69 @@
+package sshserver
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// NewHostKey creates a new ed25519 host key.
+// Returns:
+// - the signer of the key
+// - the key in OpenSSH PEM form, to save
+// - an error if the key cannot be created
+func NewHostKey() (ssh.Signer, []byte, error) {
+
+	_, private_key, err := ed25519.GenerateKey(rand.Reader)
+	if nil != err {
+		return nil, nil, err
+	}
+
+	block, err := ssh.MarshalPrivateKey(private_key, "tetris host key")
+	if nil != err {
+		return nil, nil, err
+	}
+
+	signer, err := ssh.NewSignerFromKey(private_key)
+	if nil != err {
+		return nil, nil, err
+	}
+
+	return signer, pem.EncodeToMemory(block), nil
+}
+
+// LoadHostKey reads the host key saved at the path, or creates a new one and
+// saves it there when there is no file, so clients see the same key after a
+// restart.
+// Returns:
+// - the signer of the key
+// - an error if the file cannot be read or written or holds no key
+func LoadHostKey(path string) (ssh.Signer, error) {
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		signer, data, err := NewHostKey()
+		if nil != err {
+			return nil, fmt.Errorf("cannot create host key: %w", err)
+		}
+		if err := os.WriteFile(path, data, 0600); nil != err {
+			return nil, fmt.Errorf("cannot save host key: %w", err)
+		}
+		return signer, nil
+	}
+	if nil != err {
+		return nil, fmt.Errorf("cannot read host key: %w", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(data)
+	if nil != err {
+		return nil, fmt.Errorf("cannot load host key %s: %w", path, err)
+	}
+
+	return signer, nil
+}
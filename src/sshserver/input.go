@@ -0,0 +1,88 @@
+package sshserver
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/nsf/termbox-go"
+)
+
+// DOC: An Event is a key pressed in a session, like a termbox key event.
+// Special keys set Key and leave Ch zero, and other keys set Ch.
+type Event struct {
+	Key termbox.Key
+	Ch  rune
+}
+
+// DOC: The special keys of single bytes read from a terminal.
+var controlKeys = map[rune]termbox.Key{
+	0x03: termbox.KeyCtrlC,
+	0x04: termbox.KeyCtrlD,
+	0x08: termbox.KeyBackspace,
+	0x09: termbox.KeyTab,
+	0x0a: termbox.KeyEnter,
+	0x0d: termbox.KeyEnter,
+	0x1b: termbox.KeyEsc,
+	0x20: termbox.KeySpace,
+	0x7f: termbox.KeyBackspace2,
+}
+
+// DOC: The final byte of the escape sequence of each arrow key, after either
+// "ESC [" or "ESC O".
+var arrowKeys = map[byte]termbox.Key{
+	'A': termbox.KeyArrowUp,
+	'B': termbox.KeyArrowDown,
+	'C': termbox.KeyArrowRight,
+	'D': termbox.KeyArrowLeft,
+}
+
+// readEvents sends the keys read from a terminal until reading fails or done
+// is closed, and then closes the channel.  An escape sequence must arrive in
+// a single read to be seen as one key, which terminals do.
+func readEvents(r io.Reader, events chan<- Event, done <-chan struct{}) {
+
+	defer close(events)
+
+	// send returns false once done is closed.
+	send := func(e Event) bool {
+		select {
+		case events <- e:
+			return true
+		case <-done:
+			return false
+		}
+	}
+
+	in := bufio.NewReader(r)
+	for {
+		ch, _, err := in.ReadRune()
+		if nil != err {
+			return
+		}
+
+		// GOAL: read an arrow key as one event
+		if 0x1b == ch && 2 <= in.Buffered() {
+			if prefix, _ := in.Peek(2); '[' == prefix[0] || 'O' == prefix[0] {
+				if key, ok := arrowKeys[prefix[1]]; ok {
+					in.Discard(2)
+					if !send(Event{Key: key}) {
+						return
+					}
+					continue
+				}
+			}
+		}
+
+		e := Event{Ch: ch}
+		if key, ok := controlKeys[ch]; ok {
+			e = Event{Key: key}
+		} else if ch < 0x20 {
+			// GOAL: ignore other control keys
+			continue
+		}
+
+		if !send(e) {
+			return
+		}
+	}
+}
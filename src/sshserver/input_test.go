@@ -0,0 +1,36 @@
+package sshserver
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nsf/termbox-go"
+)
+
+func TestReadEvents(t *testing.T) {
+
+	events := make(chan Event)
+	done := make(chan struct{})
+	defer close(done)
+	go readEvents(strings.NewReader("h\x1b[D\x1bOA \r\x01\x1bé\x03"), events, done)
+
+	var got []Event
+	for e := range events {
+		got = append(got, e)
+	}
+
+	want := []Event{
+		{Ch: 'h'},
+		{Key: termbox.KeyArrowLeft},
+		{Key: termbox.KeyArrowUp},
+		{Key: termbox.KeySpace},
+		{Key: termbox.KeyEnter},
+		{Key: termbox.KeyEsc},
+		{Ch: 'é'},
+		{Key: termbox.KeyCtrlC},
+	}
+	if !slices.Equal(want, got) {
+		t.Errorf("Events not expected.  got: %v  want: %v", got, want)
+	}
+}
@@ -0,0 +1,127 @@
+package sshserver
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// DOC: The number of scores kept on the leaderboard.
+const LeaderboardSize = 10
+
+// DOC: A Lobby is shared by every session of a server.  It knows who is
+// connected and what they are playing, and keeps the leaderboard of the best
+// finished games.
+type Lobby struct {
+	mu      sync.Mutex
+	players map[int]*Player
+	next_id int
+	scores  []Score
+}
+
+// DOC: A Player is a session in the lobby.  Game is the id of the game being
+// played in the hub of the server, empty in the lobby.
+type Player struct {
+	ID    int
+	Name  string
+	Game  string
+	Score int
+}
+
+// DOC: A Score is a finished game on the leaderboard.
+type Score struct {
+	Name   string
+	Score  int
+	Lines  int
+	Pieces int
+	When   time.Time
+}
+
+// NewLobby creates an empty lobby.
+func NewLobby() *Lobby {
+	return &Lobby{players: map[int]*Player{}}
+}
+
+// Join adds a player with the name and returns the id of the player.
+func (l *Lobby) Join(name string) int {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.next_id++
+	l.players[l.next_id] = &Player{ID: l.next_id, Name: name}
+
+	return l.next_id
+}
+
+// Leave removes the player.
+func (l *Lobby) Leave(id int) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.players, id)
+}
+
+// Playing sets the game the player is playing and its score, or an empty game
+// once the player is back in the lobby.
+func (l *Lobby) Playing(id int, game string, score int) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if p, ok := l.players[id]; ok {
+		p.Game, p.Score = game, score
+	}
+}
+
+// Players returns a copy of the players, in the order they joined.
+func (l *Lobby) Players() []Player {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	players := make([]Player, 0, len(l.players))
+	for _, p := range l.players {
+		players = append(players, *p)
+	}
+	slices.SortFunc(players, func(a Player, b Player) int { return a.ID - b.ID })
+
+	return players
+}
+
+// Record adds a finished game to the leaderboard if it is among the best.
+// Returns:
+// - the place of the score on the leaderboard from 1, or 0 if it is not on it
+func (l *Lobby) Record(score Score) int {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// GOAL: rank a tie below the scores already on the leaderboard
+	place, _ := slices.BinarySearchFunc(l.scores, score, func(a Score, b Score) int {
+		if a.Score >= b.Score {
+			return -1
+		}
+		return 1
+	})
+	if place >= LeaderboardSize {
+		return 0
+	}
+
+	l.scores = slices.Insert(l.scores, place, score)
+	if len(l.scores) > LeaderboardSize {
+		l.scores = l.scores[:LeaderboardSize]
+	}
+
+	return place + 1
+}
+
+// Leaderboard returns a copy of the best scores, best first.
+func (l *Lobby) Leaderboard() []Score {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]Score{}, l.scores...)
+}
@@ -0,0 +1,50 @@
+package sshserver
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLobbyPlayers(t *testing.T) {
+
+	l := NewLobby()
+	alice := l.Join("alice")
+	bob := l.Join("bob")
+	l.Playing(bob, "3", 120)
+
+	players := l.Players()
+	if 2 != len(players) || "alice" != players[0].Name || "" != players[0].Game || "3" != players[1].Game || 120 != players[1].Score {
+		t.Errorf("Players not expected.  got: %+v", players)
+	}
+
+	l.Leave(alice)
+	if players := l.Players(); 1 != len(players) || "bob" != players[0].Name {
+		t.Errorf("Players after leaving not expected.  got: %+v", players)
+	}
+}
+
+func TestLobbyLeaderboard(t *testing.T) {
+
+	l := NewLobby()
+	if place := l.Record(Score{Name: "a", Score: 50}); 1 != place {
+		t.Errorf("Place not expected.  got: %v  want: %v", place, 1)
+	}
+	if place := l.Record(Score{Name: "b", Score: 80}); 1 != place {
+		t.Errorf("Place not expected.  got: %v  want: %v", place, 1)
+	}
+	if place := l.Record(Score{Name: "c", Score: 50}); 3 != place {
+		t.Errorf("Place of a tie not expected.  got: %v  want: %v", place, 3)
+	}
+
+	for i := 0; i < LeaderboardSize; i++ {
+		l.Record(Score{Name: fmt.Sprint(i), Score: 100 + i})
+	}
+	if place := l.Record(Score{Name: "d", Score: 60}); 0 != place {
+		t.Errorf("Place off the leaderboard not expected.  got: %v  want: %v", place, 0)
+	}
+
+	scores := l.Leaderboard()
+	if LeaderboardSize != len(scores) || 109 != scores[0].Score || 100 != scores[LeaderboardSize-1].Score {
+		t.Errorf("Leaderboard not expected.  got: %+v", scores)
+	}
+}
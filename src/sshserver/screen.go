@@ -0,0 +1,153 @@
+package sshserver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/nsf/termbox-go"
+)
+
+// DOC: A Screen is a grid of cells drawn like termbox draws the local
+// terminal, but written as ANSI escape sequences to any writer, such as the
+// channel of an SSH session.  Cells are set in a back buffer and Flush writes
+// only the cells that changed since the last flush.
+type Screen struct {
+	w      io.Writer
+	width  int
+	height int
+	back   []cell
+	front  []cell
+	full   bool
+}
+
+// DOC: cell is one character on the screen and its colours.
+type cell struct {
+	ch rune
+	fg termbox.Attribute
+	bg termbox.Attribute
+}
+
+// NewScreen creates a screen of the size that writes to w.
+func NewScreen(w io.Writer, width int, height int) *Screen {
+	s := &Screen{w: w}
+	s.Resize(width, height)
+	return s
+}
+
+// Init switches the terminal to its alternate screen and hides the cursor.
+func (s *Screen) Init() error {
+	_, err := io.WriteString(s.w, "\x1b[?1049h\x1b[?25l")
+	return err
+}
+
+// Close shows the cursor and switches the terminal back to its main screen.
+func (s *Screen) Close() error {
+	_, err := io.WriteString(s.w, "\x1b[0m\x1b[?25h\x1b[?1049l")
+	return err
+}
+
+// Size returns the width and height of the screen.
+func (s *Screen) Size() (int, int) {
+	return s.width, s.height
+}
+
+// Resize changes the size of the screen and clears it.  The next flush
+// redraws every cell.
+func (s *Screen) Resize(width int, height int) {
+
+	s.width, s.height = max(width, 0), max(height, 0)
+	s.back = make([]cell, s.width*s.height)
+	s.front = make([]cell, s.width*s.height)
+	s.full = true
+
+	s.Clear(termbox.ColorDefault, termbox.ColorDefault)
+}
+
+// Clear fills the back buffer with spaces in the colours, like termbox.Clear.
+func (s *Screen) Clear(fg termbox.Attribute, bg termbox.Attribute) {
+	for i := range s.back {
+		s.back[i] = cell{ch: ' ', fg: fg, bg: bg}
+	}
+}
+
+// SetCell sets a cell of the back buffer, like termbox.SetCell.  Cells outside
+// the screen are ignored.
+func (s *Screen) SetCell(x int, y int, ch rune, fg termbox.Attribute, bg termbox.Attribute) {
+	if x < 0 || x >= s.width || y < 0 || y >= s.height {
+		return
+	}
+	s.back[y*s.width+x] = cell{ch: ch, fg: fg, bg: bg}
+}
+
+// Print sets the cells of a string in white on black from the row and column.
+func (s *Screen) Print(y int, x int, str string) {
+	for _, c := range str {
+		s.SetCell(x, y, c, termbox.ColorWhite, termbox.ColorBlack)
+		x++
+	}
+}
+
+// Flush writes the cells that changed since the last flush, like
+// termbox.Flush.
+func (s *Screen) Flush() error {
+
+	var out bytes.Buffer
+	if s.full {
+		out.WriteString("\x1b[0m\x1b[2J")
+	}
+
+	// GOAL: only move the cursor and change colours when needed
+	next_x, next_y := -1, -1
+	var colours *cell
+	for y := 0; y < s.height; y++ {
+		for x := 0; x < s.width; x++ {
+			c := s.back[y*s.width+x]
+			if !s.full && c == s.front[y*s.width+x] {
+				continue
+			}
+			if x != next_x || y != next_y {
+				fmt.Fprintf(&out, "\x1b[%d;%dH", y+1, x+1)
+			}
+			if nil == colours || c.fg != colours.fg || c.bg != colours.bg {
+				out.WriteString(sgr(c.fg, c.bg))
+				colours = &cell{fg: c.fg, bg: c.bg}
+			}
+			out.WriteRune(c.ch)
+			next_x, next_y = x+1, y
+		}
+	}
+
+	copy(s.front, s.back)
+	s.full = false
+
+	if 0 == out.Len() {
+		return nil
+	}
+	_, err := s.w.Write(out.Bytes())
+	return err
+}
+
+// sgr returns the escape sequence that selects the termbox colours.
+func sgr(fg termbox.Attribute, bg termbox.Attribute) string {
+
+	// colour gives the code of a termbox colour above the base code of the
+	// default colour, 39 for foreground or 49 for background.
+	colour := func(a termbox.Attribute, base int) int {
+		c := int(a & 0xFF)
+		if 0 == c || c > 8 {
+			return base
+		}
+		return base - 10 + c
+	}
+
+	seq := fmt.Sprintf("\x1b[0;%d;%d", colour(fg, 39), colour(bg, 49))
+	if 0 != fg&termbox.AttrBold {
+		seq += ";1"
+	}
+	if 0 != fg&termbox.AttrReverse {
+		seq += ";7"
+	}
+
+	return seq + "m"
+}
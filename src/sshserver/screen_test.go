@@ -0,0 +1,52 @@
+package sshserver
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nsf/termbox-go"
+)
+
+func TestScreenFlushesChanges(t *testing.T) {
+
+	var out bytes.Buffer
+	s := NewScreen(&out, 4, 2)
+
+	s.Print(0, 0, "ab")
+	if err := s.Flush(); nil != err {
+		t.Fatalf("Flush failed.  %v", err)
+	}
+	want := "\x1b[0m\x1b[2J\x1b[1;1H\x1b[0;37;40mab\x1b[0;39;49m  \x1b[2;1H    "
+	if want != out.String() {
+		t.Errorf("First flush not expected.  got: %q  want: %q", out.String(), want)
+	}
+
+	// GOAL: only the changed cell is written
+	out.Reset()
+	s.Print(0, 0, "ab")
+	s.SetCell(3, 1, 'z', termbox.ColorRed, termbox.ColorDefault)
+	s.SetCell(9, 9, 'x', termbox.ColorRed, termbox.ColorDefault)
+	s.Flush()
+	want = "\x1b[2;4H\x1b[0;31;49mz"
+	if want != out.String() {
+		t.Errorf("Second flush not expected.  got: %q  want: %q", out.String(), want)
+	}
+
+	out.Reset()
+	s.Flush()
+	if 0 != out.Len() {
+		t.Errorf("Flush without changes not expected.  got: %q", out.String())
+	}
+
+	// GOAL: a resize redraws the whole screen
+	out.Reset()
+	s.Resize(2, 1)
+	s.Flush()
+	want = "\x1b[0m\x1b[2J\x1b[1;1H\x1b[0;39;49m  "
+	if want != out.String() {
+		t.Errorf("Flush after resize not expected.  got: %q  want: %q", out.String(), want)
+	}
+	if width, height := s.Size(); 2 != width || 1 != height {
+		t.Errorf("Size not expected.  got: %v by %v  want: 2 by 1", width, height)
+	}
+}
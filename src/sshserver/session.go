@@ -0,0 +1,346 @@
+package sshserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nsf/termbox-go"
+	"golang.org/x/crypto/ssh"
+	"superfrink.net/tetris/engine"
+	"superfrink.net/tetris/render"
+)
+
+// DOC: How often the lobby is redrawn to show what the other sessions do.
+const lobbyRefresh = 500 * time.Millisecond
+
+// DOC: The keys of a game, the keys of the console game and the keys of the
+// second player of its versus mode.
+var gameKeys = map[rune]byte{
+	'h': engine.PlayInputMoveLeft,
+	'l': engine.PlayInputMoveRight,
+	'r': engine.PlayInputRotate,
+	'e': engine.PlayInputRotateCCW,
+	'w': engine.PlayInputRotate180,
+	'd': engine.PlayInputDrop,
+	's': engine.PlayInputHardDrop,
+	'c': engine.PlayInputHold,
+	'p': engine.PlayInputPause,
+	'q': engine.PlayInputStop,
+}
+var gameSpecialKeys = map[termbox.Key]byte{
+	termbox.KeyArrowLeft:  engine.PlayInputMoveLeft,
+	termbox.KeyArrowRight: engine.PlayInputMoveRight,
+	termbox.KeyArrowUp:    engine.PlayInputRotate,
+	termbox.KeyArrowDown:  engine.PlayInputDrop,
+	termbox.KeySpace:      engine.PlayInputHardDrop,
+	termbox.KeyEnter:      engine.PlayInputHold,
+}
+
+// DOC: session is one player connected over SSH.  Everything but the sizes
+// is only used by the goroutine running the session.
+type session struct {
+	server  *Server
+	name    string
+	channel ssh.Channel
+	sizes   <-chan [2]int
+
+	id     int
+	screen *Screen
+	events <-chan Event
+}
+
+// DOC: waited is what a session woke up for while showing a screen.
+type waited int
+
+const (
+	waitedEvent waited = iota
+	waitedResize
+	waitedUpdate
+	waitedTick
+	waitedQuit
+)
+
+// run shows the lobby and the games the player chooses from it until the
+// player quits, the client disconnects or the context is cancelled.
+func (s *session) run(ctx context.Context) {
+
+	size := <-s.sizes
+	s.screen = NewScreen(s.channel, size[0], size[1])
+	s.screen.Init()
+	defer s.screen.Close()
+
+	events := make(chan Event)
+	done := make(chan struct{})
+	defer close(done)
+	go readEvents(s.channel, events, done)
+	s.events = events
+
+	s.id = s.server.lobby.Join(s.name)
+	defer s.server.lobby.Leave(s.id)
+
+	for {
+		next, game := s.showLobby(ctx)
+		switch next {
+		case 'n':
+			if !s.play(ctx) {
+				return
+			}
+		case 'w':
+			if !s.watch(ctx, game) {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// wait waits for a key, a new terminal size, an update of a game or a tick.
+// A nil channel is never waited for.  The screen is resized before returning.
+// Returns:
+// - what the session woke up for, waitedQuit when the client has gone or
+// pressed ctrl-c
+// - the key
+// - the state of the game, nil when the updates have ended
+func (s *session) wait(ctx context.Context, updates <-chan *engine.Game, tick <-chan time.Time) (waited, Event, *engine.Game) {
+
+	select {
+	case e, ok := <-s.events:
+		if !ok || termbox.KeyCtrlC == e.Key {
+			return waitedQuit, e, nil
+		}
+		return waitedEvent, e, nil
+	case size := <-s.sizes:
+		s.screen.Resize(size[0], size[1])
+		return waitedResize, Event{}, nil
+	case state := <-updates:
+		return waitedUpdate, Event{}, state
+	case <-tick:
+		return waitedTick, Event{}, nil
+	case <-ctx.Done():
+		return waitedQuit, Event{}, nil
+	}
+}
+
+// showLobby shows the players, the games being played and the leaderboard
+// until the player chooses what to do.
+// Returns:
+// - 'n' to play a new game, 'w' to watch a game or 'q' to quit
+// - the id of the game to watch
+func (s *session) showLobby(ctx context.Context) (rune, string) {
+
+	ticker := time.NewTicker(lobbyRefresh)
+	defer ticker.Stop()
+
+	for {
+		games := s.drawLobby()
+		s.screen.Flush()
+
+		what, e, _ := s.wait(ctx, nil, ticker.C)
+		switch {
+		case waitedQuit == what:
+			return 'q', ""
+		case waitedEvent != what:
+		case 'n' == e.Ch || termbox.KeyEnter == e.Key:
+			return 'n', ""
+		case 'q' == e.Ch || termbox.KeyCtrlD == e.Key:
+			return 'q', ""
+		case '1' <= e.Ch && e.Ch <= '9' && int(e.Ch-'1') < len(games):
+			return 'w', games[e.Ch-'1'].Game
+		}
+	}
+}
+
+// drawLobby draws the lobby and returns the players in the games listed, in
+// the order of the keys that watch them.
+func (s *session) drawLobby() []Player {
+
+	s.screen.Clear(termbox.ColorBlack, termbox.ColorBlack)
+	s.screen.Print(0, 0, fmt.Sprintf("TETRIS  -  welcome %s", s.name))
+
+	// GOAL: Draw the leaderboard
+	s.screen.Print(2, 0, "Leaderboard")
+	for n, score := range s.server.lobby.Leaderboard() {
+		s.screen.Print(3+n, 0, fmt.Sprintf("%2d. %-12.12s %7d %4d lines", n+1, score.Name, score.Score, score.Lines))
+	}
+
+	// GOAL: Draw the games being played and who is in the lobby
+	var games []Player
+	var idle []string
+	for _, p := range s.server.lobby.Players() {
+		if "" != p.Game && p.ID != s.id && len(games) < 9 {
+			games = append(games, p)
+		} else if "" == p.Game {
+			idle = append(idle, p.Name)
+		}
+	}
+
+	s.screen.Print(2, 42, "Games")
+	for n, p := range games {
+		s.screen.Print(3+n, 42, fmt.Sprintf("%d. %-12.12s %7d", n+1, p.Name, p.Score))
+	}
+	s.screen.Print(4+max(len(games), 1), 42, "In the lobby")
+	for n, name := range idle {
+		s.screen.Print(5+max(len(games), 1)+n, 42, name)
+	}
+
+	_, height := s.screen.Size()
+	s.screen.Print(max(height-1, 15), 0, "n = new game   1-9 = watch a game   q = quit")
+
+	return games
+}
+
+// play plays a new game until it is over and the player presses a key.
+// Returns:
+// - false if the session is over
+func (s *session) play(ctx context.Context) bool {
+
+	config := s.server.config
+	config.Seed = time.Now().UTC().UnixNano()
+
+	game, err := s.server.hub.Start(ctx, config)
+	if nil != err {
+		return s.message(ctx, fmt.Sprintf("cannot start game: %v", err))
+	}
+	defer game.Stop()
+
+	watcher, err := game.Watch(false)
+	if nil != err {
+		return s.message(ctx, fmt.Sprintf("cannot play game: %v", err))
+	}
+	defer game.Unwatch(watcher)
+
+	s.server.lobby.Playing(s.id, game.ID(), 0)
+	defer s.server.lobby.Playing(s.id, "", 0)
+
+	legend := "q = quit  r = rotate  e = rotate ccw  w = rotate 180  h = left  l = right  d = drop  s = hard drop  c = hold  p = pause"
+	on_key := func(e Event) bool {
+		input, ok := gameKeys[e.Ch]
+		if 0 == e.Ch {
+			input, ok = gameSpecialKeys[e.Key]
+		}
+		if ok {
+			game.Input(input)
+		}
+		return false
+	}
+	on_state := func(state *engine.Game) string {
+		s.server.lobby.Playing(s.id, game.ID(), state.Score)
+		if engine.StateGameOver != state.State {
+			return ""
+		}
+
+		place := s.server.lobby.Record(Score{
+			Name:   s.name,
+			Score:  state.Score,
+			Lines:  state.ScoreLineCount,
+			Pieces: state.ScorePieceCount,
+			When:   time.Now(),
+		})
+		if 0 < place {
+			return fmt.Sprintf("number %d on the leaderboard", place)
+		}
+		return ""
+	}
+
+	return s.show(ctx, watcher.Updates(), legend, on_key, on_state)
+}
+
+// watch shows the game being played by another player until it is over and
+// the player presses a key, or the player presses q.
+// Returns:
+// - false if the session is over
+func (s *session) watch(ctx context.Context, id string) bool {
+
+	game, err := s.server.hub.Find(id)
+	if nil != err {
+		return s.message(ctx, fmt.Sprintf("cannot watch game: %v", err))
+	}
+
+	watcher, err := game.Watch(true)
+	if nil != err {
+		return s.message(ctx, fmt.Sprintf("cannot watch game: %v", err))
+	}
+	defer game.Unwatch(watcher)
+
+	on_key := func(e Event) bool {
+		return 'q' == e.Ch
+	}
+	on_state := func(state *engine.Game) string {
+		return ""
+	}
+
+	return s.show(ctx, watcher.Updates(), "q = back to the lobby", on_key, on_state)
+}
+
+// show draws each state of a game with the legend below it until the game is
+// over and a key is pressed.  Keys pressed before then go to on_key, which
+// returns true to stop showing the game.  Each state goes to on_state, which
+// returns a note to show once the game is over.
+// Returns:
+// - false if the session is over
+func (s *session) show(ctx context.Context, updates <-chan *engine.Game, legend string, on_key func(Event) bool, on_state func(*engine.Game) string) bool {
+
+	var last *engine.Game
+	over, note := false, ""
+
+	for {
+		s.screen.Clear(termbox.ColorBlack, termbox.ColorBlack)
+		if nil != last {
+			render.Game(s.screen, 0, last)
+			s.screen.Print(last.GameRows+3, 0, legend)
+		}
+		if over {
+			s.screen.Print(12, 20, "GAME OVER")
+			s.screen.Print(13, 17, "press any key")
+			s.screen.Print(15, 15, note)
+		}
+		s.screen.Flush()
+
+		what, e, state := s.wait(ctx, updates, nil)
+		switch what {
+		case waitedQuit:
+			return false
+
+		case waitedEvent:
+			if over || on_key(e) {
+				return true
+			}
+
+		case waitedUpdate:
+			if nil == state {
+				// CLAIM: the game is over and its final state was received
+				updates, over = nil, true
+				break
+			}
+			last = state
+			if text := on_state(state); "" != text {
+				note = text
+			}
+			if engine.StateGameOver == state.State {
+				over = true
+			}
+		}
+	}
+}
+
+// message shows the text until a key is pressed.
+// Returns:
+// - false if the session is over
+func (s *session) message(ctx context.Context, text string) bool {
+
+	for {
+		s.screen.Clear(termbox.ColorBlack, termbox.ColorBlack)
+		s.screen.Print(0, 0, text)
+		s.screen.Print(2, 0, "press any key")
+		s.screen.Flush()
+
+		switch what, _, _ := s.wait(ctx, nil, nil); what {
+		case waitedQuit:
+			return false
+		case waitedEvent:
+			return true
+		}
+	}
+}
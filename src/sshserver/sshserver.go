@@ -0,0 +1,224 @@
+// Package sshserver hosts tetris games over SSH.  Each session with a
+// terminal gets its own screen, drawn like the console game, and starts in a
+// lobby shared by every session.  The lobby shows who is connected, the games
+// being played and the leaderboard, and from it a player starts a game or
+// watches the game of another player.  Any SSH client can connect, and its
+// user name is the name of the player.
+package sshserver
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"superfrink.net/tetris/engine"
+	"superfrink.net/tetris/server"
+)
+
+// DOC: A Server hosts games for the SSH sessions it serves.
+type Server struct {
+	ssh_config *ssh.ServerConfig
+	config     engine.Config
+	hub        *server.Hub
+	lobby      *Lobby
+}
+
+// DOC: The size of a terminal that does not know its size.
+const (
+	defaultColumns = 80
+	defaultRows    = 24
+)
+
+// DOC: ptyRequest is the payload of a "pty-req" request, from RFC 4254.
+type ptyRequest struct {
+	Term    string
+	Columns uint32
+	Rows    uint32
+	Width   uint32
+	Height  uint32
+	Modes   string
+}
+
+// DOC: windowChange is the payload of a "window-change" request.
+type windowChange struct {
+	Columns uint32
+	Rows    uint32
+	Width   uint32
+	Height  uint32
+}
+
+// DOC: exitStatus is the payload of an "exit-status" request.
+type exitStatus struct {
+	Status uint32
+}
+
+// NewServer creates a server with the host key for games of the
+// configuration.  Clients are not authenticated.
+// Returns:
+// - the server
+// - an error if the configuration is not valid
+func NewServer(host_key ssh.Signer, config engine.Config) (*Server, error) {
+
+	if err := config.Validate(); nil != err {
+		return nil, err
+	}
+
+	ssh_config := &ssh.ServerConfig{NoClientAuth: true}
+	ssh_config.AddHostKey(host_key)
+
+	return &Server{
+		ssh_config: ssh_config,
+		config:     config,
+		hub:        server.NewHub(),
+		lobby:      NewLobby(),
+	}, nil
+}
+
+// Lobby returns the lobby shared by the sessions of the server.
+func (s *Server) Lobby() *Lobby {
+	return s.lobby
+}
+
+// Serve accepts SSH connections until the context is cancelled, and then
+// closes the listener and every connection.
+// Returns:
+// - nil once the context is cancelled and every connection is closed
+// - an error if accepting fails
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	stop := context.AfterFunc(ctx, func() { listener.Close() })
+	defer stop()
+
+	for {
+		conn, err := listener.Accept()
+		if nil != err {
+			if nil != ctx.Err() {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+
+			stop := context.AfterFunc(ctx, func() { conn.Close() })
+			defer stop()
+
+			s.serveConn(ctx, conn)
+		}()
+	}
+}
+
+// serveConn serves the session channels of one SSH connection until it
+// closes.
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+
+	ssh_conn, channels, requests, err := ssh.NewServerConn(conn, s.ssh_config)
+	if nil != err {
+		return
+	}
+	defer ssh_conn.Close()
+	go ssh.DiscardRequests(requests)
+
+	var sessions sync.WaitGroup
+	defer sessions.Wait()
+
+	for new_channel := range channels {
+		if "session" != new_channel.ChannelType() {
+			new_channel.Reject(ssh.UnknownChannelType, "only sessions are served")
+			continue
+		}
+
+		channel, channel_requests, err := new_channel.Accept()
+		if nil != err {
+			continue
+		}
+
+		sessions.Add(1)
+		go func() {
+			defer sessions.Done()
+			s.serveChannel(ctx, ssh_conn.User(), channel, channel_requests)
+		}()
+	}
+}
+
+// serveChannel answers the requests of a session channel and runs the
+// session once the client asks for a shell.
+func (s *Server) serveChannel(ctx context.Context, name string, channel ssh.Channel, requests <-chan *ssh.Request) {
+
+	var running sync.WaitGroup
+	defer running.Wait()
+	defer channel.Close()
+
+	sizes := make(chan [2]int, 1)
+	has_pty, started := false, false
+
+	for request := range requests {
+		ok := false
+
+		switch request.Type {
+		case "pty-req":
+			var pty ptyRequest
+			if err := ssh.Unmarshal(request.Payload, &pty); nil == err && !started {
+				has_pty, ok = true, true
+				offerSize(sizes, int(pty.Columns), int(pty.Rows))
+			}
+
+		case "window-change":
+			var change windowChange
+			if err := ssh.Unmarshal(request.Payload, &change); nil == err {
+				ok = true
+				offerSize(sizes, int(change.Columns), int(change.Rows))
+			}
+
+		case "shell":
+			ok = !started
+		}
+
+		request.Reply(ok, nil)
+
+		if "shell" == request.Type && ok {
+			started = true
+			session := &session{server: s, name: name, channel: channel, sizes: sizes}
+
+			running.Add(1)
+			go func(has_pty bool) {
+				defer running.Done()
+
+				status := uint32(0)
+				if has_pty {
+					session.run(ctx)
+				} else {
+					channel.Write([]byte("tetris needs a terminal, connect with ssh -t\r\n"))
+					status = 1
+				}
+
+				channel.SendRequest("exit-status", false, ssh.Marshal(exitStatus{status}))
+				channel.Close()
+			}(has_pty)
+		}
+	}
+}
+
+// offerSize replaces any terminal size the session has not taken yet with the
+// new size.  A terminal that does not know its size gets the default size.
+// Sizes are only offered from the requests of one channel, so there is never
+// more than one offer at a time.
+func offerSize(sizes chan [2]int, columns int, rows int) {
+
+	if 0 == columns || 0 == rows {
+		columns, rows = defaultColumns, defaultRows
+	}
+
+	select {
+	case <-sizes:
+	default:
+	}
+	sizes <- [2]int{columns, rows}
+}
@@ -0,0 +1,226 @@
+package sshserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"superfrink.net/tetris/engine"
+)
+
+// DOC: testTerminal is an SSH session of a test client with a terminal.
+type testTerminal struct {
+	t       *testing.T
+	session *ssh.Session
+	stdin   io.Writer
+
+	mu     sync.Mutex
+	output bytes.Buffer
+}
+
+// Write collects the output of the session.
+func (term *testTerminal) Write(p []byte) (int, error) {
+	term.mu.Lock()
+	defer term.mu.Unlock()
+	return term.output.Write(p)
+}
+
+// startServer serves bucket games dealt by a history randomizer on a loopback
+// port until the test ends.
+func startServer(t *testing.T) (*Server, string) {
+
+	host_key, _, err := NewHostKey()
+	if nil != err {
+		t.Fatalf("Host key not created.  %v", err)
+	}
+
+	config := engine.DefaultBucketConfig()
+	config.Randomizer = engine.NewTGMRandomizer()
+	s, err := NewServer(host_key, config)
+	if nil != err {
+		t.Fatalf("Server not created.  %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		t.Fatalf("Listen failed.  %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(ctx, listener)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-served; nil != err {
+			t.Errorf("Serve failed.  %v", err)
+		}
+	})
+
+	return s, listener.Addr().String()
+}
+
+// dial opens a session as the user, with a terminal unless pty is false.
+func dial(t *testing.T, addr string, user string, pty bool) *testTerminal {
+
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            user,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if nil != err {
+		t.Fatalf("Dial failed.  %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	session, err := client.NewSession()
+	if nil != err {
+		t.Fatalf("Session not created.  %v", err)
+	}
+
+	term := &testTerminal{t: t, session: session}
+	session.Stdout = term
+	term.stdin, err = session.StdinPipe()
+	if nil != err {
+		t.Fatalf("Stdin not opened.  %v", err)
+	}
+
+	if pty {
+		if err := session.RequestPty("xterm", 24, 80, ssh.TerminalModes{}); nil != err {
+			t.Fatalf("Terminal not allocated.  %v", err)
+		}
+	}
+	if err := session.Shell(); nil != err {
+		t.Fatalf("Shell not started.  %v", err)
+	}
+
+	return term
+}
+
+// press types the keys.
+func (term *testTerminal) press(keys string) {
+	if _, err := io.WriteString(term.stdin, keys); nil != err {
+		term.t.Fatalf("Keys not sent.  %v", err)
+	}
+}
+
+// waitFor waits until the output contains the text, failing after a timeout.
+func (term *testTerminal) waitFor(text string) {
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		term.mu.Lock()
+		found := bytes.Contains(term.output.Bytes(), []byte(text))
+		term.mu.Unlock()
+		if found {
+			return
+		}
+		if time.Now().After(deadline) {
+			term.t.Fatalf("Output does not contain %q.", text)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// until waits until the condition holds, failing after a timeout.
+func until(t *testing.T, what string, condition func() bool) {
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s.", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPlayToLeaderboard(t *testing.T) {
+
+	s, addr := startServer(t)
+
+	alice := dial(t, addr, "alice", true)
+	alice.waitFor("welcome alice")
+
+	alice.press("n")
+	alice.waitFor("Score:")
+
+	// GOAL: hard drop until the bucket is full
+	until(t, "game over", func() bool {
+		alice.press("s")
+		return 0 < len(s.Lobby().Leaderboard())
+	})
+	alice.waitFor("GAME OVER")
+
+	scores := s.Lobby().Leaderboard()
+	if "alice" != scores[0].Name || 0 == scores[0].Pieces {
+		t.Errorf("Leaderboard not expected.  got: %+v", scores)
+	}
+
+	// CLAIM: the game dealt from its own copy of the history
+	history := s.config.Randomizer.(*engine.HistoryRandomizer).History
+	if !slices.Equal(engine.NewTGMRandomizer().History, history) {
+		t.Errorf("Randomizer of the configuration used by the game.  history: %v", history)
+	}
+
+	// GOAL: back to the lobby and out
+	alice.press("x")
+	until(t, "the lobby", func() bool {
+		players := s.Lobby().Players()
+		return 1 == len(players) && "" == players[0].Game
+	})
+	alice.press("q")
+	if err := alice.session.Wait(); nil != err {
+		t.Errorf("Session did not end cleanly.  %v", err)
+	}
+	until(t, "leaving", func() bool { return 0 == len(s.Lobby().Players()) })
+}
+
+func TestWatchAnotherPlayer(t *testing.T) {
+
+	s, addr := startServer(t)
+
+	alice := dial(t, addr, "alice", true)
+	alice.waitFor("welcome alice")
+	alice.press("n")
+
+	bob := dial(t, addr, "bob", true)
+	bob.waitFor("welcome bob")
+	until(t, "alice to play", func() bool {
+		for _, p := range s.Lobby().Players() {
+			if "alice" == p.Name && "" != p.Game {
+				return true
+			}
+		}
+		return false
+	})
+	bob.waitFor("1. alice")
+
+	bob.press("1")
+	bob.waitFor("q = back to the lobby")
+
+	// GOAL: the game ends for the spectator too
+	alice.press("q")
+	alice.waitFor("GAME OVER")
+	bob.waitFor("GAME OVER")
+}
+
+func TestSessionNeedsTerminal(t *testing.T) {
+
+	_, addr := startServer(t)
+
+	term := dial(t, addr, "carol", false)
+	err := term.session.Wait()
+
+	var exit_error *ssh.ExitError
+	if !errors.As(err, &exit_error) || 1 != exit_error.ExitStatus() {
+		t.Errorf("Exit not expected.  got: %v  want: status 1", err)
+	}
+	term.waitFor("needs a terminal")
+}
@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"time"
 
 	"github.com/nsf/termbox-go"
+	"superfrink.net/tetris/ai"
 	"superfrink.net/tetris/engine"
+	"superfrink.net/tetris/render"
 )
 
 // tbprint is based on from https://github.com/jjinux/gotetris/
@@ -17,13 +23,286 @@ func tbprint(y int, x int, str string) {
 	}
 }
 
+// DOC: termboxCanvas draws on the termbox screen for the render package.
+type termboxCanvas struct{}
+
+// Print writes the string with tbprint.
+func (termboxCanvas) Print(row int, col int, str string) {
+	tbprint(row, col, str)
+}
+
+// drawGame draws a game with its left side at the specified column.
+func drawGame(col int, game_state *engine.Game) {
+
+	render.Game(termboxCanvas{}, col, game_state)
+
+	if true {
+		// FIXME: only show when debugging
+		info_col := render.InfoColumn(col, game_state)
+		tbprint(7, info_col, fmt.Sprintf("Piece    : %2d", game_state.Piece))
+		tbprint(8, info_col, fmt.Sprintf("Rotation : %2d", game_state.PieceRotation))
+		tbprint(9, info_col, fmt.Sprintf("Piece row: %2d", game_state.PiecePosRow))
+		tbprint(10, info_col, fmt.Sprintf("Piece col: %2d", game_state.PiecePosCol))
+	}
+}
+
+// DOC: Keys of each player in a versus match.  The first player uses the
+// keys of the single player game and the second player uses special keys.
+var versusPlayerOneKeys = map[rune]byte{
+	'h': engine.PlayInputMoveLeft,
+	'l': engine.PlayInputMoveRight,
+	'r': engine.PlayInputRotate,
+	'e': engine.PlayInputRotateCCW,
+	'w': engine.PlayInputRotate180,
+	'd': engine.PlayInputDrop,
+	's': engine.PlayInputHardDrop,
+	'c': engine.PlayInputHold,
+}
+
+var versusPlayerTwoKeys = map[termbox.Key]byte{
+	termbox.KeyArrowLeft:  engine.PlayInputMoveLeft,
+	termbox.KeyArrowRight: engine.PlayInputMoveRight,
+	termbox.KeyArrowUp:    engine.PlayInputRotate,
+	termbox.KeyArrowDown:  engine.PlayInputDrop,
+	termbox.KeySpace:      engine.PlayInputHardDrop,
+	termbox.KeyEnter:      engine.PlayInputHold,
+}
+
+// DOC: The column of the left side of each player's game in a versus match.
+var versusColumns = [2]int{0, 48}
+
+// playVersus plays a versus match of two players side by side on one
+// keyboard, or of a player against the AI player.
+func playVersus(config engine.Config, with_ai bool) {
+
+	match, err := engine.NewMatch(config, engine.GuidelineAttack)
+	if err != nil {
+		log.Fatal("create match: ", err)
+	}
+
+	player_input_chs := [2]chan byte{make(chan byte, 5), make(chan byte, 5)}
+	match_state_ch := make(chan *engine.Match, 5)
+	match.MainMatchLoop(context.Background(), nil, [2]<-chan byte{player_input_chs[0], player_input_chs[1]}, match_state_ch)
+
+	// GOAL: Setup the screen
+	err = termbox.Init()
+	if err != nil {
+		log.Fatal("init", err)
+	}
+	defer termbox.Close()
+
+	termbox.Clear(termbox.ColorBlack, termbox.ColorBlack)
+
+	legend_row := config.Rows + 3
+	tbprint(legend_row, 0, "q = quit\tp = pause")
+	tbprint(legend_row+1, 0, "player 1: r = rotate  e = rotate ccw  w = rotate 180  h = left  l = right  d = drop  s = hard drop  c = hold")
+	if with_ai {
+		tbprint(legend_row+2, 0, "player 2: AI")
+	} else {
+		tbprint(legend_row+2, 0, "player 2: up = rotate  left  right  down = drop  space = hard drop  enter = hold")
+	}
+	termbox.Flush()
+
+	// GOAL: Create a channel for user input
+	local_user_input_ch := make(chan termbox.Event)
+
+	go func() {
+		for {
+			event := termbox.PollEvent()
+			if termbox.EventKey == event.Type {
+				local_user_input_ch <- event
+			}
+		}
+	}()
+
+	// GOAL: Let the AI player press a key on each tick
+	var ai_player *ai.Player
+	var ai_tick <-chan time.Time
+	if with_ai {
+		ai_player = ai.NewPlayer(ai.DefaultWeights)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		ai_tick = ticker.C
+	}
+
+	// Wait until the match is ready
+	match_state := <-match_state_ch
+
+	// Main match loop
+	quit := false
+	for {
+		select {
+
+		case event := <-local_user_input_ch:
+			if quit {
+				return
+			}
+
+			switch {
+			case 'q' == event.Ch:
+				return
+			case 'p' == event.Ch:
+				player_input_chs[0] <- engine.PlayInputPause
+			case 0 != event.Ch:
+				if input, ok := versusPlayerOneKeys[event.Ch]; ok {
+					player_input_chs[0] <- input
+				}
+			case !with_ai:
+				if input, ok := versusPlayerTwoKeys[event.Key]; ok {
+					player_input_chs[1] <- input
+				}
+			}
+
+		case <-ai_tick:
+			if nil != match_state_ch {
+				for _, input := range ai_player.Inputs(match_state.Games[1]) {
+					player_input_chs[1] <- input
+				}
+			}
+
+		case next_state, ok := <-match_state_ch:
+			if !ok {
+				// CLAIM: the match has ended and sent its final state
+				match_state_ch = nil
+				break
+			}
+			match_state = next_state
+
+			for player, game_state := range match_state.Games {
+				col := versusColumns[player]
+				drawGame(col, game_state)
+
+				// GOAL: Draw the pending garbage meter beside the field
+				pending := match_state.PendingLines(player)
+				for i := 1; i < game_state.GameRows+1; i++ {
+					if game_state.GameRows+1-i <= pending {
+						tbprint(i, col+game_state.GameColumns+3, "#")
+					} else {
+						tbprint(i, col+game_state.GameColumns+3, " ")
+					}
+				}
+			}
+		}
+
+		// GOAL: Check if the match is over
+		if match_state.Over && !quit {
+			for player := range match_state.Games {
+				col := versusColumns[player]
+				switch match_state.Winner {
+				case player:
+					tbprint(12, col+20, "WINNER")
+				case -1:
+					tbprint(12, col+20, "DRAW")
+				default:
+					tbprint(12, col+20, "GAME OVER")
+				}
+				tbprint(13, col+17, "press any key")
+			}
+			quit = true
+		}
+		// GOAL: Update the screen
+		termbox.Flush()
+	}
+}
+
+// saveFile creates the file at path and writes it with save.
+func saveFile(path string, save func(io.Writer) error) error {
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = save(file)
+	if close_err := file.Close(); err == nil {
+		err = close_err
+	}
+	return err
+}
+
+// loadFile opens the file at path and reads it with load.
+func loadFile(path string, load func(io.Reader) error) error {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return load(file)
+}
+
 func main() {
 
 	var flag_bucketgame = flag.Bool("b", false, "Play a bucket game instead.")
+	var flag_save = flag.String("save", "", "Save the game to this file when quitting.")
+	var flag_load = flag.String("load", "", "Resume the game saved in this file.")
+	var flag_record = flag.String("record", "", "Record a replay of the game to this file.")
+	var flag_replay = flag.String("replay", "", "Watch the replay in this file instead of playing.")
+	var flag_ai = flag.Bool("ai", false, "Watch the AI player play the game, or play against it with -versus.")
+	var flag_versus = flag.Bool("versus", false, "Play a versus match of two players side by side.")
 	flag.Parse()
 
+	if *flag_versus {
+		config := engine.DefaultConfig()
+		if *flag_bucketgame {
+			config = engine.DefaultBucketConfig()
+		}
+		config.Seed = time.Now().UTC().UnixNano()
+		playVersus(config, *flag_ai)
+		return
+	}
+
+	// GOAL: Create an instance of the game
+	var game *engine.Game
+	var game_state *engine.Game
+	var game_user_input_ch chan<- byte
+	var game_output_channel <-chan *engine.Game
+	var watched *engine.Replay
+	var replay *engine.Replay
+	var err error
+
+	switch {
+	case "" != *flag_replay:
+		err = loadFile(*flag_replay, func(r io.Reader) error {
+			watched, err = engine.LoadReplay(r)
+			return err
+		})
+	case "" != *flag_load:
+		err = loadFile(*flag_load, func(r io.Reader) error {
+			game, err = engine.LoadGame(r)
+			return err
+		})
+	default:
+		config := engine.DefaultConfig()
+		if *flag_bucketgame {
+			config = engine.DefaultBucketConfig()
+		}
+		config.Seed = time.Now().UTC().UnixNano()
+		game, err = engine.NewSteppedGame(config)
+	}
+	if err != nil {
+		log.Fatal("create game: ", err)
+	}
+
+	if nil != watched {
+		// CLAIM: watching a replay, so there is no player input
+		game_output_channel, err = watched.Play(context.Background(), nil)
+	} else {
+		if "" != *flag_record {
+			replay, err = game.RecordReplay()
+			if err != nil {
+				log.Fatal("record: ", err)
+			}
+		}
+		game_user_input_ch, game_output_channel, err = engine.ResumeGame(context.Background(), game)
+	}
+	if err != nil {
+		log.Fatal("start game: ", err)
+	}
+
 	// GOAL: Setup the screen
-	err := termbox.Init()
+	err = termbox.Init()
 	if err != nil {
 		log.Fatal("init", err)
 	}
@@ -33,7 +312,11 @@ func main() {
 
 	// GOAL: Setup the keystroke legend
 	// FIXME: It would be good to use variables for each key
-	tbprint(21, 0, "q = quit\tr = rotate\th = left\tl = right\td = drop\tp = pause")
+	if nil == game_user_input_ch {
+		tbprint(21, 0, "q = quit")
+	} else {
+		tbprint(21, 0, "q = quit\tr = rotate\te = rotate ccw\tw = rotate 180\th = left\tl = right\td = drop\ts = hard drop\tc = hold\tp = pause")
+	}
 
 	termbox.Flush()
 
@@ -50,15 +333,14 @@ func main() {
 	}()
 	var key rune
 
-	// GOAL: Create an instance of the game
-	var game_state *engine.Game
-	var game_user_input_ch chan<- byte
-	var game_output_channel <-chan *engine.Game
-
-	if *flag_bucketgame {
-		_, game_user_input_ch, game_output_channel = engine.NewBucketGame()
-	} else {
-		_, game_user_input_ch, game_output_channel = engine.NewGame()
+	// GOAL: Let the AI player press a key on each tick
+	var ai_player *ai.Player
+	var ai_tick <-chan time.Time
+	if *flag_ai && nil != game_user_input_ch {
+		ai_player = ai.NewPlayer(ai.DefaultWeights)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		ai_tick = ticker.C
 	}
 
 	// Wait until the game is ready
@@ -75,11 +357,31 @@ mainloop:
 				break mainloop
 			}
 
+			if nil == game_user_input_ch {
+				// GOAL: only quitting is possible while watching a replay
+				if 'q' == key {
+					break mainloop
+				}
+				continue
+			}
+
 			switch key {
 			case 'q':
+				if "" != *flag_save && engine.StateGameOver != game_state.State {
+					// GOAL: save the game as last shown so it can be resumed
+					if err := saveFile(*flag_save, game_state.Save); err != nil {
+						tbprint(15, 15, fmt.Sprintf("save failed: %v", err))
+					} else {
+						tbprint(15, 15, fmt.Sprintf("saved to %s", *flag_save))
+					}
+				}
 				game_user_input_ch <- engine.PlayInputStop
 			case 'd':
 				game_user_input_ch <- engine.PlayInputDrop
+			case 's':
+				game_user_input_ch <- engine.PlayInputHardDrop
+			case 'c':
+				game_user_input_ch <- engine.PlayInputHold
 			case 'h':
 				game_user_input_ch <- engine.PlayInputMoveLeft
 			case 'l':
@@ -88,46 +390,45 @@ mainloop:
 				game_user_input_ch <- engine.PlayInputPause
 			case 'r':
 				game_user_input_ch <- engine.PlayInputRotate
+			case 'e':
+				game_user_input_ch <- engine.PlayInputRotateCCW
+			case 'w':
+				game_user_input_ch <- engine.PlayInputRotate180
 			}
 
-		case game_state = <-game_output_channel:
-
-			// GOAL: Draw the game field
-			for i := 0; i < game_state.GameRows+2; i++ {
-				for j := 0; j < game_state.GameColumns+2; j++ {
-					if 0 != game_state.Field[i][j] {
-						tbprint(i, j, "X")
-					} else {
-						tbprint(i, j, " ")
-					}
+		case <-ai_tick:
+			if nil != game_output_channel {
+				for _, input := range ai_player.Inputs(game_state) {
+					game_user_input_ch <- input
 				}
 			}
 
-			// GOAL: Draw the piece in play
-			for i := 0; i < 4; i++ {
-				for j := 0; j < 4; j++ {
-					if 0 != game_state.PieceMap[game_state.Piece][game_state.PieceRotation][i][j] {
-						tbprint(game_state.PiecePosRow+i, game_state.PiecePosCol+j, "*")
-					}
+		case next_state, ok := <-game_output_channel:
+			if !ok {
+				// CLAIM: the game or replay has ended and sent its final state
+				game_output_channel = nil
+				if engine.StateGameOver != game_state.State {
+					tbprint(12, 18, "END OF REPLAY")
+					tbprint(13, 17, "press any key")
+					quit = true
 				}
+				break
 			}
+			game_state = next_state
 
-			// GOAL: Draw the score
-			tbprint(2, 15, fmt.Sprintf("Pieces: %d", game_state.ScorePieceCount))
-			tbprint(3, 15, fmt.Sprintf("Lines:  %d", game_state.ScoreLineCount))
-
-			if true {
-				// FIXME: only show when debugging
-				tbprint(7, 15, fmt.Sprintf("Piece    : %2d", game_state.Piece))
-				tbprint(8, 15, fmt.Sprintf("Rotation : %2d", game_state.PieceRotation))
-				tbprint(9, 15, fmt.Sprintf("Piece row: %2d", game_state.PiecePosRow))
-				tbprint(10, 15, fmt.Sprintf("Piece col: %2d", game_state.PiecePosCol))
-			}
-
+			drawGame(0, game_state)
 		}
 
 		// GOAL: Check if the game is over
 		if game_state != nil && engine.StateGameOver == game_state.State {
+			if !quit && nil != replay {
+				// CLAIM: the game is over so the replay is complete
+				if err := saveFile(*flag_record, replay.Save); err != nil {
+					tbprint(16, 15, fmt.Sprintf("record failed: %v", err))
+				} else {
+					tbprint(16, 15, fmt.Sprintf("replay saved to %s", *flag_record))
+				}
+			}
 			tbprint(12, 20, "GAME OVER")
 			tbprint(13, 17, "press any key")
 			quit = true
@@ -0,0 +1,430 @@
+// Package web hosts tetris games for browsers with a JSON API over HTTP and a
+// WebSocket stream of each game, and serves a canvas client that plays them.
+//
+// The API is:
+//   - POST /api/games creates a game and returns its id and the token that
+//     plays it.  The body may choose the "seed", the "rows" and "columns" of
+//     the field, the "mode", "standard" or "bucket", and the "randomizer".
+//   - GET /api/games lists the games being played.
+//   - GET /api/games/{id} returns the latest snapshot of the game.
+//   - POST /api/games/{id}/inputs plays the game with the "token" from its
+//     creation and a list of "inputs", the names in server.InputNames.
+//   - GET /api/games/{id}/ws is a WebSocket of the messages of package
+//     server, a "state" after every change of the game, or "delta" messages
+//     with ?updates=delta.
+//
+// Errors are answered with a JSON object with an "error".  A game without
+// input for the idle timeout is stopped, and finished games are forgotten.
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+	"superfrink.net/tetris/engine"
+	"superfrink.net/tetris/server"
+)
+
+// DOC: The largest field a game can be created with.
+const (
+	MaxRows    = 40
+	MaxColumns = 40
+)
+
+// DOC: The most inputs one request can play, and the largest request body.
+const (
+	maxInputs    = 64
+	maxBodyBytes = 1 << 16
+)
+
+//go:embed static
+var static embed.FS
+
+// DOC: A Server is the http.Handler of the API and the client.  Games run
+// until they are over, idle or the context of the server is cancelled.
+type Server struct {
+	ctx  context.Context
+	idle time.Duration
+	hub  *server.Hub
+	mux  *http.ServeMux
+
+	mu      sync.Mutex
+	players map[string]*player
+}
+
+// DOC: player is the token that plays a game, and the input activity that
+// keeps it from going idle.
+type player struct {
+	game    *server.HostedGame
+	token   string
+	touched chan struct{}
+}
+
+// DOC: CreateRequest is the body of a request to create a game.  Zero values
+// pick the defaults of the mode.
+type CreateRequest struct {
+	Seed       *int64 `json:"seed,omitempty"`
+	Rows       int    `json:"rows,omitempty"`
+	Columns    int    `json:"columns,omitempty"`
+	Mode       string `json:"mode,omitempty"`
+	Randomizer string `json:"randomizer,omitempty"`
+}
+
+// DOC: CreateResponse answers the creation of a game.
+type CreateResponse struct {
+	Game    string `json:"game"`
+	Token   string `json:"token"`
+	Seed    int64  `json:"seed"`
+	Rows    int    `json:"rows"`
+	Columns int    `json:"columns"`
+}
+
+// DOC: InputRequest is the body of a request to play a game.
+type InputRequest struct {
+	Token  string   `json:"token"`
+	Inputs []string `json:"inputs"`
+}
+
+// NewServer creates a server whose games run until the context is cancelled.
+// Games without input for the idle timeout are stopped, unless it is zero.
+func NewServer(ctx context.Context, idle time.Duration) *Server {
+
+	s := &Server{
+		ctx:     ctx,
+		idle:    idle,
+		hub:     server.NewHub(),
+		mux:     http.NewServeMux(),
+		players: map[string]*player{},
+	}
+
+	client, _ := fs.Sub(static, "static")
+	s.mux.Handle("/", http.FileServer(http.FS(client)))
+	s.mux.HandleFunc("/api/games", s.handleGames)
+	s.mux.HandleFunc("/api/games/", s.handleGame)
+
+	return s
+}
+
+// ServeHTTP answers the API and serves the client.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleGames creates and lists games.
+func (s *Server) handleGames(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.hub.List())
+	case http.MethodPost:
+		s.create(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+// handleGame routes /api/games/{id} and the paths below it.
+func (s *Server) handleGame(w http.ResponseWriter, r *http.Request) {
+
+	id, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/games/"), "/")
+
+	var method string
+	var handle func(http.ResponseWriter, *http.Request, *server.HostedGame)
+	switch rest {
+	case "":
+		method, handle = http.MethodGet, s.snapshot
+	case "inputs":
+		method, handle = http.MethodPost, s.inputs
+	case "ws":
+		method, handle = http.MethodGet, s.stream
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("no path %q", r.URL.Path))
+		return
+	}
+
+	if method != r.Method {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	game, err := s.hub.Find(id)
+	if nil != err {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	handle(w, r, game)
+}
+
+// create starts a game from a CreateRequest and answers with its token.
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+
+	var request CreateRequest
+	if err := readJSON(w, r, &request); nil != err {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	config, err := request.Config()
+	if nil != err {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	token, err := newToken()
+	if nil != err {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	game, err := s.hub.Start(s.ctx, config)
+	if nil != err {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	p := &player{game: game, token: token, touched: make(chan struct{}, 1)}
+	s.mu.Lock()
+	s.players[game.ID()] = p
+	s.mu.Unlock()
+
+	go func() {
+		s.watchIdle(p)
+
+		s.mu.Lock()
+		delete(s.players, game.ID())
+		s.mu.Unlock()
+	}()
+
+	writeJSON(w, http.StatusCreated, CreateResponse{
+		Game:    game.ID(),
+		Token:   token,
+		Seed:    config.Seed,
+		Rows:    config.Rows,
+		Columns: config.Columns,
+	})
+}
+
+// Config returns the game configuration the request asks for.
+// Returns:
+// - the configuration, with a seed from the clock if none was asked for
+// - an error if the mode, size or randomizer is not valid
+func (request CreateRequest) Config() (engine.Config, error) {
+
+	var config engine.Config
+	switch request.Mode {
+	case "", "standard":
+		config = engine.DefaultConfig()
+	case "bucket":
+		config = engine.DefaultBucketConfig()
+	default:
+		return config, fmt.Errorf("unknown mode %q", request.Mode)
+	}
+
+	if 0 != request.Rows {
+		config.Rows = request.Rows
+	}
+	if 0 != request.Columns {
+		config.Columns = request.Columns
+	}
+	if config.Rows < 1 || config.Rows > MaxRows || config.Columns < 1 || config.Columns > MaxColumns {
+		return config, fmt.Errorf("field of %d rows by %d columns is not from 1 by 1 to %d by %d", config.Rows, config.Columns, MaxRows, MaxColumns)
+	}
+
+	if "" != request.Randomizer {
+		randomizer, err := engine.NewRandomizer(request.Randomizer)
+		if nil != err {
+			return config, err
+		}
+		config.Randomizer = randomizer
+	}
+
+	config.Seed = time.Now().UTC().UnixNano()
+	if nil != request.Seed {
+		config.Seed = *request.Seed
+	}
+
+	return config, config.Validate()
+}
+
+// watchIdle stops the game of the player once it goes the idle timeout
+// without input, and returns when the game is over.
+func (s *Server) watchIdle(p *player) {
+
+	if 0 == s.idle {
+		<-p.game.Done()
+		return
+	}
+
+	timer := time.NewTimer(s.idle)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-p.touched:
+			timer.Stop()
+			select {
+			case <-timer.C:
+			default:
+			}
+			timer.Reset(s.idle)
+		case <-timer.C:
+			p.game.Stop()
+			<-p.game.Done()
+			return
+		case <-p.game.Done():
+			return
+		}
+	}
+}
+
+// snapshot answers with the latest state of the game.
+func (s *Server) snapshot(w http.ResponseWriter, r *http.Request, game *server.HostedGame) {
+
+	state := game.Last()
+	if nil == state {
+		// GOAL: wait for the first state of a game that just started
+		watcher, err := game.Watch(false)
+		if nil != err {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		state = <-watcher.Updates()
+		game.Unwatch(watcher)
+		if nil == state {
+			writeError(w, http.StatusNotFound, fmt.Errorf("game %s is over", game.ID()))
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, server.NewSnapshot(state))
+}
+
+// inputs plays the inputs of an InputRequest on the game.
+func (s *Server) inputs(w http.ResponseWriter, r *http.Request, game *server.HostedGame) {
+
+	var request InputRequest
+	if err := readJSON(w, r, &request); nil != err {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.mu.Lock()
+	p := s.players[game.ID()]
+	s.mu.Unlock()
+	if nil == p || p.token != request.Token {
+		writeError(w, http.StatusForbidden, errors.New("token does not play the game"))
+		return
+	}
+
+	if len(request.Inputs) > maxInputs {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%d inputs is more than %d", len(request.Inputs), maxInputs))
+		return
+	}
+	inputs := make([]byte, len(request.Inputs))
+	for i, name := range request.Inputs {
+		input, ok := server.InputNames[name]
+		if !ok {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("unknown input %q", name))
+			return
+		}
+		inputs[i] = input
+	}
+
+	select {
+	case p.touched <- struct{}{}:
+	default:
+	}
+
+	for _, input := range inputs {
+		if err := game.Input(input); nil != err {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// stream sends the game to a WebSocket as the messages of package server
+// until the game is over or the socket is closed.
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, game *server.HostedGame) {
+
+	updates := r.URL.Query().Get("updates")
+	if "" == updates {
+		updates = server.UpdatesSnapshot
+	}
+	if server.UpdatesSnapshot != updates && server.UpdatesDelta != updates {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown updates %q", updates))
+		return
+	}
+
+	websocket.Handler(func(ws *websocket.Conn) {
+		watcher, err := game.Watch(true)
+		if nil != err {
+			websocket.JSON.Send(ws, server.Message{Type: "error", Error: err.Error()})
+			return
+		}
+
+		// GOAL: stop watching once the client closes the socket
+		go func() {
+			var ignored []byte
+			for nil == websocket.Message.Receive(ws, &ignored) {
+			}
+			game.Unwatch(watcher)
+		}()
+
+		server.Pump(watcher, updates, func(m server.Message) error {
+			return websocket.JSON.Send(ws, m)
+		})
+	}).ServeHTTP(w, r)
+}
+
+// newToken returns a random token that cannot be guessed.
+func newToken() (string, error) {
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); nil != err {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// readJSON decodes the body of the request into v.  An empty body leaves v
+// as it is.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) error {
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); nil != err && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("bad request body: %w", err)
+	}
+
+	return nil
+}
+
+// writeJSON answers with the status and v as JSON.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers with the status and the error as JSON.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,251 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+	"superfrink.net/tetris/engine"
+	"superfrink.net/tetris/server"
+)
+
+// startServer serves a web server on a loopback port until the test ends.
+func startServer(t *testing.T, idle time.Duration) *httptest.Server {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ts := httptest.NewServer(NewServer(ctx, idle))
+	t.Cleanup(func() {
+		cancel()
+		ts.Close()
+	})
+
+	return ts
+}
+
+// call makes an API request and decodes the JSON answer into reply, unless
+// reply is nil.
+// Returns:
+// - the status of the answer
+func call(t *testing.T, ts *httptest.Server, method string, path string, body any, reply any) int {
+
+	var reader io.Reader
+	if nil != body {
+		b, err := json.Marshal(body)
+		if nil != err {
+			t.Fatalf("Marshal failed.  %v", err)
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	request, err := http.NewRequest(method, ts.URL+path, reader)
+	if nil != err {
+		t.Fatalf("Request not created.  %v", err)
+	}
+	response, err := ts.Client().Do(request)
+	if nil != err {
+		t.Fatalf("Request failed.  %v", err)
+	}
+	defer response.Body.Close()
+
+	if nil != reply {
+		if err := json.NewDecoder(response.Body).Decode(reply); nil != err {
+			t.Fatalf("Answer to %s %s not decoded.  %v", method, path, err)
+		}
+	}
+
+	return response.StatusCode
+}
+
+// create creates a standard game with the seed.
+func create(t *testing.T, ts *httptest.Server, seed int64) CreateResponse {
+
+	var created CreateResponse
+	status := call(t, ts, "POST", "/api/games", CreateRequest{Seed: &seed}, &created)
+	if http.StatusCreated != status {
+		t.Fatalf("Create status not expected.  got: %v  want: %v", status, http.StatusCreated)
+	}
+
+	return created
+}
+
+// dialStream opens the WebSocket of the game.
+func dialStream(t *testing.T, ts *httptest.Server, game string, query string) *websocket.Conn {
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/api/games/" + game + "/ws" + query
+	ws, err := websocket.Dial(url, "", ts.URL)
+	if nil != err {
+		t.Fatalf("Dial failed.  %v", err)
+	}
+	t.Cleanup(func() { ws.Close() })
+
+	return ws
+}
+
+// until receives messages from the WebSocket until one satisfies done,
+// failing after a timeout.
+func until(t *testing.T, ws *websocket.Conn, done func(m server.Message) bool) server.Message {
+
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var m server.Message
+		if err := websocket.JSON.Receive(ws, &m); nil != err {
+			t.Fatalf("Receive failed.  %v", err)
+		}
+		if done(m) {
+			return m
+		}
+	}
+}
+
+func TestCreatePlayAndStream(t *testing.T) {
+
+	ts := startServer(t, 0)
+
+	created := create(t, ts, 7)
+	if engine.DefaultGameRows != created.Rows || engine.DefaultGameColumns != created.Columns || 7 != created.Seed || "" == created.Token {
+		t.Errorf("Created game not expected.  got: %+v", created)
+	}
+
+	var snapshot server.Snapshot
+	if status := call(t, ts, "GET", "/api/games/"+created.Game, nil, &snapshot); http.StatusOK != status {
+		t.Fatalf("Snapshot status not expected.  got: %v  want: %v", status, http.StatusOK)
+	}
+	if engine.DefaultGameRows != len(snapshot.Field) || 0 != snapshot.Score {
+		t.Errorf("Snapshot not expected.  got: %d rows and score %d", len(snapshot.Field), snapshot.Score)
+	}
+
+	ws := dialStream(t, ts, created.Game, "?updates=delta")
+	first := until(t, ws, func(m server.Message) bool { return true })
+	if "state" != first.Type || nil == first.State || engine.DefaultGameRows != len(first.State.Field) {
+		t.Fatalf("First message not expected.  got: %+v", first)
+	}
+
+	inputs := InputRequest{Token: created.Token, Inputs: []string{"left", "hard_drop"}}
+	if status := call(t, ts, "POST", "/api/games/"+created.Game+"/inputs", inputs, nil); http.StatusNoContent != status {
+		t.Fatalf("Input status not expected.  got: %v  want: %v", status, http.StatusNoContent)
+	}
+
+	// GOAL: follow the deltas to the locked piece
+	state := first.State
+	pieces := state.Pieces
+	until(t, ws, func(m server.Message) bool {
+		if "delta" != m.Type {
+			t.Fatalf("Message type not expected.  got: %v  want: delta", m.Type)
+		}
+		if err := state.Apply(m.State, m.Rows); nil != err {
+			t.Fatalf("Delta not applied.  %v", err)
+		}
+		return pieces+1 == state.Pieces
+	})
+
+	blocks := 0
+	for _, row := range state.Field {
+		for _, cell := range row {
+			if 0 != cell {
+				blocks++
+			}
+		}
+	}
+	if 4 != blocks {
+		t.Errorf("Blocks after hard drop not expected.  got: %v  want: %v", blocks, 4)
+	}
+
+	var games []server.GameInfo
+	call(t, ts, "GET", "/api/games", nil, &games)
+	if 1 != len(games) || created.Game != games[0].Game || 1 != games[0].Spectators {
+		t.Errorf("Games not expected.  got: %+v", games)
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+
+	ts := startServer(t, 0)
+	created := create(t, ts, 1)
+	game := "/api/games/" + created.Game
+
+	tests := []struct {
+		method string
+		path   string
+		body   any
+		want   int
+	}{
+		{"POST", "/api/games", CreateRequest{Mode: "tiny"}, http.StatusBadRequest},
+		{"POST", "/api/games", CreateRequest{Rows: MaxRows + 1}, http.StatusBadRequest},
+		{"POST", "/api/games", CreateRequest{Randomizer: "dice"}, http.StatusBadRequest},
+		{"POST", "/api/games", map[string]int{"speed": 3}, http.StatusBadRequest},
+		{"DELETE", "/api/games", nil, http.StatusMethodNotAllowed},
+		{"GET", "/api/games/99", nil, http.StatusNotFound},
+		{"GET", game + "/moves", nil, http.StatusNotFound},
+		{"GET", game + "/inputs", nil, http.StatusMethodNotAllowed},
+		{"POST", game + "/inputs", InputRequest{Token: "guess", Inputs: []string{"left"}}, http.StatusForbidden},
+		{"POST", game + "/inputs", InputRequest{Token: created.Token, Inputs: []string{"jump"}}, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		var reply map[string]string
+		status := call(t, ts, test.method, test.path, test.body, &reply)
+		if test.want != status {
+			t.Errorf("Status of %s %s not expected.  got: %v  want: %v", test.method, test.path, status, test.want)
+		}
+		if "" == reply["error"] {
+			t.Errorf("Error of %s %s not expected.  got: %v", test.method, test.path, reply)
+		}
+	}
+
+	var bucket CreateResponse
+	seed := int64(2)
+	call(t, ts, "POST", "/api/games", CreateRequest{Seed: &seed, Mode: "bucket", Rows: 6}, &bucket)
+	if 6 != bucket.Rows || 3 != bucket.Columns {
+		t.Errorf("Bucket game not expected.  got: %+v", bucket)
+	}
+}
+
+func TestIdleGameStops(t *testing.T) {
+
+	ts := startServer(t, 50*time.Millisecond)
+	created := create(t, ts, 3)
+
+	ws := dialStream(t, ts, created.Game, "")
+	over := until(t, ws, func(m server.Message) bool {
+		return "state" == m.Type && "over" == m.State.State
+	})
+	if "over" != over.State.State {
+		t.Errorf("State not expected.  got: %v  want: over", over.State.State)
+	}
+
+	// CLAIM: the game leaves the server once its stream is closed
+	var m server.Message
+	if err := websocket.JSON.Receive(ws, &m); nil == err {
+		t.Errorf("Stream not closed.  got: %+v", m)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for http.StatusNotFound != call(t, ts, "GET", "/api/games/"+created.Game, nil, nil) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Idle game not forgotten.")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClientServed(t *testing.T) {
+
+	ts := startServer(t, 0)
+
+	response, err := ts.Client().Get(ts.URL + "/")
+	if nil != err {
+		t.Fatalf("Request failed.  %v", err)
+	}
+	defer response.Body.Close()
+
+	page, _ := io.ReadAll(response.Body)
+	if http.StatusOK != response.StatusCode || !bytes.Contains(page, []byte("<canvas")) {
+		t.Errorf("Client not served.  got: %v %q", response.StatusCode, page[:min(len(page), 80)])
+	}
+}